@@ -0,0 +1,352 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lstack-org/utils/pkg/encipherment"
+)
+
+// algorithmLegacy 使用 AesEncrypt 生成旧格式密文
+const algorithmLegacy = "legacy"
+
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: encipher %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func runEncrypt(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := newFlagSet("encrypt", "[text]")
+	keys := addKeyFlags(fs)
+	algorithm := fs.String("alg", "", fmt.Sprintf("algorithm: %s or %s (default keyring algorithm or %s)",
+		strings.Join(encipherment.Ciphers(), ", "), algorithmLegacy, encipherment.AlgorithmAesGcm))
+	ad := fs.String("ad", "", "additional authenticated data")
+	in := fs.String("in", "", "input file, - for stdin")
+	out := fs.String("out", "", "output file (default stdout)")
+	stream := fs.Bool("stream", false, "binary streaming encryption for large files, requires a single key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *stream {
+		key, err := keys.resolveKey("-stream")
+		if err != nil {
+			return err
+		}
+		return copyStream(*in, *out, stdin, stdout, func(r io.Reader, w io.Writer) error {
+			writer, err := encipherment.NewEncryptWriter(w, key)
+			if err != nil {
+				return err
+			}
+			if _, err = io.Copy(writer, r); err != nil {
+				return err
+			}
+			return writer.Close()
+		})
+	}
+
+	plaintext, err := readInput(fs.Args(), *in, stdin)
+	if err != nil {
+		return err
+	}
+	key, keyring, err := keys.resolve()
+	if err != nil {
+		return err
+	}
+	var ciphertext string
+	switch {
+	case *algorithm == algorithmLegacy:
+		if keyring != nil {
+			return fmt.Errorf("%s requires a single key, not a keyring", algorithmLegacy)
+		}
+		ciphertext, err = encipherment.AesEncrypt(string(plaintext), string(key))
+	case keyring != nil:
+		if *algorithm != "" {
+			if err = keyring.SetAlgorithm(*algorithm); err != nil {
+				return err
+			}
+		}
+		ciphertext, err = keyring.Seal(plaintext, adBytes(*ad))
+	default:
+		if *algorithm == "" {
+			*algorithm = encipherment.AlgorithmAesGcm
+		}
+		ciphertext, err = encipherment.Encrypt(*algorithm, plaintext, key, adBytes(*ad))
+	}
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, stdout, []byte(ciphertext+"\n"))
+}
+
+func runDecrypt(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := newFlagSet("decrypt", "[ciphertext]")
+	keys := addKeyFlags(fs)
+	ad := fs.String("ad", "", "additional authenticated data")
+	in := fs.String("in", "", "input file, - for stdin")
+	out := fs.String("out", "", "output file (default stdout)")
+	stream := fs.Bool("stream", false, "decrypt data produced by encrypt -stream")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *stream {
+		key, err := keys.resolveKey("-stream")
+		if err != nil {
+			return err
+		}
+		return copyStream(*in, *out, stdin, stdout, func(r io.Reader, w io.Writer) error {
+			reader, err := encipherment.NewDecryptReader(r, key)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(w, reader)
+			return err
+		})
+	}
+
+	input, err := readInput(fs.Args(), *in, stdin)
+	if err != nil {
+		return err
+	}
+	ciphertext := strings.TrimSpace(string(input))
+	key, keyring, err := keys.resolve()
+	if err != nil {
+		return err
+	}
+	var plaintext []byte
+	if keyring != nil {
+		plaintext, err = keyring.Open(ciphertext, adBytes(*ad))
+	} else {
+		//单个密钥同时支持 AesEncrypt 生成的旧格式
+		plaintext, _, err = encipherment.DecryptAny(ciphertext, key, adBytes(*ad))
+	}
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, stdout, plaintext)
+}
+
+func runGenkey(args []string, _ io.Reader, stdout io.Writer) error {
+	fs := newFlagSet("genkey", "")
+	size := fs.Int("size", 32, "key size in bytes: 16, 24 or 32")
+	id := fs.String("id", "", "key id in the keyring file (default k<timestamp>)")
+	keyringPath := fs.String("keyring", "", "add the key to this keyring file instead of printing it, the file is created if missing")
+	primary := fs.Bool("primary", false, "make the new key the primary key of the keyring file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *size != 16 && *size != 24 && *size != 32 {
+		return encipherment.ErrInvalidKey
+	}
+	key := make([]byte, *size)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	if *keyringPath == "" {
+		_, err := fmt.Fprintln(stdout, formatKey(key))
+		return err
+	}
+
+	keyring := encipherment.NewKeyring()
+	data, err := ioutil.ReadFile(*keyringPath)
+	switch {
+	case err == nil:
+		if len(bytes.TrimSpace(data)) > 0 {
+			if keyring, err = encipherment.ParseKeyring(data); err != nil {
+				return err
+			}
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+	if *id == "" {
+		*id = "k" + time.Now().Format("20060102150405")
+	}
+	if err = keyring.Add(*id, key); err != nil {
+		return fmt.Errorf("add key to %s: %w", *keyringPath, err)
+	}
+	if *primary {
+		if err = keyring.SetPrimary(*id); err != nil {
+			return err
+		}
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		data, err = encipherment.MarshalKeyringJSON(keyring)
+	} else {
+		data, err = encipherment.MarshalKeyring(keyring)
+	}
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(*keyringPath, data, 0600); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, *id)
+	return err
+}
+
+func runDerive(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := newFlagSet("derive", "")
+	kdf := fs.String("kdf", encipherment.KDFScrypt, fmt.Sprintf("%s or %s", encipherment.KDFScrypt, encipherment.KDFPbkdf2))
+	params := fs.String("params", "", "derive again with params printed by a previous run, -kdf and -size are ignored")
+	size := fs.Int("size", 32, "key size in bytes: 16, 24 or 32")
+	passphrase := fs.String("passphrase", "", "passphrase (default $"+envPassphrase+" or the first line of stdin)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *passphrase == "" {
+		*passphrase = os.Getenv(envPassphrase)
+	}
+	if *passphrase == "" {
+		line, err := bufio.NewReader(stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		*passphrase = strings.TrimRight(line, "\r\n")
+	}
+	if *passphrase == "" {
+		return fmt.Errorf("passphrase is empty")
+	}
+
+	var derived *encipherment.DerivedKey
+	var err error
+	if *params != "" {
+		derived, err = encipherment.DeriveKeyFromString(*passphrase, *params)
+	} else {
+		var p *encipherment.KDFParams
+		switch *kdf {
+		case encipherment.KDFScrypt:
+			p, err = encipherment.NewScryptParams()
+		case encipherment.KDFPbkdf2:
+			p, err = encipherment.NewPbkdf2Params()
+		default:
+			return fmt.Errorf("unsupported kdf %s", *kdf)
+		}
+		if err != nil {
+			return err
+		}
+		p.KeyLen = *size
+		derived, err = encipherment.DeriveKey(*passphrase, p)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(stdout, "params: %s\nkey: %s\n", derived.Params, formatKey(derived.Key))
+	return err
+}
+
+func runSign(args []string, _ io.Reader, stdout io.Writer) error {
+	fs := newFlagSet("sign", "")
+	keys := addKeyFlags(fs)
+	claimsJSON := fs.String("claims", "{}", "claims as a json object")
+	ttl := fs.Duration("ttl", time.Hour, "token lifetime")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	claims := encipherment.Claims{}
+	if err := json.Unmarshal([]byte(*claimsJSON), &claims); err != nil {
+		return fmt.Errorf("invalid claims: %w", err)
+	}
+	keyring, err := keys.resolveKeyring()
+	if err != nil {
+		return err
+	}
+	token, err := encipherment.NewTokenSigner(keyring).Sign(claims, *ttl)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, token)
+	return err
+}
+
+func runVerify(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := newFlagSet("verify", "[token]")
+	keys := addKeyFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	input, err := readInput(fs.Args(), "", stdin)
+	if err != nil {
+		return err
+	}
+	keyring, err := keys.resolveKeyring()
+	if err != nil {
+		return err
+	}
+	claims, err := encipherment.NewTokenSigner(keyring).Verify(strings.TrimSpace(string(input)))
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(claims, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, string(data))
+	return err
+}
+
+// readInput 读取命令参数、文件或标准输入
+func readInput(args []string, in string, stdin io.Reader) ([]byte, error) {
+	switch {
+	case in != "" && in != "-":
+		return ioutil.ReadFile(in)
+	case in == "" && len(args) > 0:
+		return []byte(strings.Join(args, " ")), nil
+	}
+	return ioutil.ReadAll(stdin)
+}
+
+func writeOutput(out string, stdout io.Writer, data []byte) error {
+	if out == "" || out == "-" {
+		_, err := stdout.Write(data)
+		return err
+	}
+	return ioutil.WriteFile(out, data, 0600)
+}
+
+// copyStream 打开输入输出后执行fn，用于流式加解密
+func copyStream(in, out string, stdin io.Reader, stdout io.Writer, fn func(r io.Reader, w io.Writer) error) error {
+	r := stdin
+	if in != "" && in != "-" {
+		file, err := os.Open(in)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+	if out == "" || out == "-" {
+		return fn(r, stdout)
+	}
+	file, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err = fn(r, file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func adBytes(ad string) []byte {
+	if ad == "" {
+		return nil
+	}
+	return []byte(ad)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lstack-org/utils/pkg/encipherment"
+)
+
+const (
+	envKey        = "ENCIPHER_KEY"
+	envKeyring    = "ENCIPHER_KEYRING"
+	envPassphrase = "ENCIPHER_PASSPHRASE"
+	// base64KeyPrefix 标准base64编码的密钥前缀，没有前缀的key按字符串使用
+	base64KeyPrefix = "base64:"
+	// defaultKeyID 单个密钥构建密钥环时使用的密钥ID
+	defaultKeyID = "default"
+)
+
+// keyFlags 密钥相关的参数
+type keyFlags struct {
+	key     string
+	keyring string
+}
+
+func addKeyFlags(fs *flag.FlagSet) *keyFlags {
+	k := &keyFlags{}
+	fs.StringVar(&k.key, "key", "", "key string, or base64:<key> (default $"+envKey+")")
+	fs.StringVar(&k.keyring, "keyring", "", "keyring file (default $"+envKeyring+")")
+	return k
+}
+
+// resolve 获取单个密钥或密钥环，两者只会返回一个
+func (k *keyFlags) resolve() ([]byte, *encipherment.Keyring, error) {
+	switch {
+	case k.key != "":
+		key, err := parseKey(k.key)
+		return key, nil, err
+	case k.keyring != "":
+		keyring, err := encipherment.LoadKeyring(k.keyring)
+		return nil, keyring, err
+	case os.Getenv(envKey) != "":
+		key, err := parseKey(os.Getenv(envKey))
+		return key, nil, err
+	case os.Getenv(envKeyring) != "":
+		keyring, err := encipherment.LoadKeyring(os.Getenv(envKeyring))
+		return nil, keyring, err
+	}
+	return nil, nil, fmt.Errorf("no key: use -key, -keyring, $%s or $%s", envKey, envKeyring)
+}
+
+// resolveKey 只接受单个密钥，用于不支持密钥环的操作
+func (k *keyFlags) resolveKey(operation string) ([]byte, error) {
+	key, keyring, err := k.resolve()
+	if err != nil {
+		return nil, err
+	}
+	if keyring != nil {
+		return nil, fmt.Errorf("%s requires a single key, not a keyring", operation)
+	}
+	return key, nil
+}
+
+// resolveKeyring 获取密钥环，单个密钥会构建只包含该密钥的密钥环
+func (k *keyFlags) resolveKeyring() (*encipherment.Keyring, error) {
+	key, keyring, err := k.resolve()
+	if err != nil || keyring != nil {
+		return keyring, err
+	}
+	keyring = encipherment.NewKeyring()
+	if err = keyring.Add(defaultKeyID, key); err != nil {
+		return nil, err
+	}
+	return keyring, nil
+}
+
+func parseKey(s string) ([]byte, error) {
+	if !strings.HasPrefix(s, base64KeyPrefix) {
+		return []byte(s), nil
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(s, base64KeyPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", encipherment.ErrInvalidEncoding, err.Error())
+	}
+	return key, nil
+}
+
+func formatKey(key []byte) string {
+	return base64KeyPrefix + base64.StdEncoding.EncodeToString(key)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lstack-org/utils/pkg/encipherment"
+)
+
+const testKey = "1234567890123456"
+
+func runCommand(t *testing.T, stdin string, args ...string) string {
+	t.Helper()
+	stdout := &bytes.Buffer{}
+	if err := run(args, strings.NewReader(stdin), stdout); err != nil {
+		t.Fatalf("encipher %s: %v", strings.Join(args, " "), err)
+	}
+	return stdout.String()
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	//aes-siv 需要32字节以上的密钥
+	key := testKey + testKey
+	for _, algorithm := range append(encipherment.Ciphers(), "") {
+		ciphertext := runCommand(t, "", "encrypt", "-key", key, "-alg", algorithm, "-ad", "id=1", "hello world")
+		plaintext := runCommand(t, ciphertext, "decrypt", "-key", key, "-ad", "id=1")
+		if plaintext != "hello world" {
+			t.Fatalf("%s: expect hello world, but got %q", algorithm, plaintext)
+		}
+	}
+
+	//解密 AesEncrypt 生成的url参数
+	legacy, err := encipherment.AesEncrypt("user=1", testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext := runCommand(t, "", "decrypt", "-key", testKey, legacy); plaintext != "user=1" {
+		t.Fatalf("expect user=1, but got %q", plaintext)
+	}
+	ciphertext := runCommand(t, "", "encrypt", "-key", testKey, "-alg", algorithmLegacy, "user=1")
+	if strings.TrimSpace(ciphertext) != legacy {
+		t.Fatalf("expect %s, but got %s", legacy, ciphertext)
+	}
+
+	if err = run([]string{"decrypt", "-key", "6543210987654321", legacy}, nil, &bytes.Buffer{}); err == nil {
+		t.Fatal("expect error with wrong key")
+	}
+}
+
+func TestStream(t *testing.T) {
+	dir := t.TempDir()
+	plain := strings.Repeat("0123456789", 20000)
+	encrypted := filepath.Join(dir, "data.enc")
+	runCommand(t, plain, "encrypt", "-stream", "-key", testKey, "-out", encrypted)
+	if output := runCommand(t, "", "decrypt", "-stream", "-key", testKey, "-in", encrypted); output != plain {
+		t.Fatal("stream decrypt mismatch")
+	}
+}
+
+func TestKeyring(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keyring.yaml")
+	runCommand(t, "", "genkey", "-keyring", path, "-id", "k1")
+	ciphertext := runCommand(t, "", "encrypt", "-keyring", path, "secret")
+
+	runCommand(t, "", "genkey", "-keyring", path, "-id", "k2", "-primary", "-size", "16")
+	keyring, err := encipherment.LoadKeyring(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyring.Primary() != "k2" || len(keyring.IDs()) != 2 {
+		t.Fatalf("unexpected keyring: primary=%s ids=%v", keyring.Primary(), keyring.IDs())
+	}
+	if plaintext := runCommand(t, ciphertext, "decrypt", "-keyring", path); plaintext != "secret" {
+		t.Fatalf("expect secret, but got %q", plaintext)
+	}
+
+	t.Setenv(envKeyring, path)
+	token := runCommand(t, "", "sign", "-claims", `{"sub":"ops"}`, "-ttl", "1m")
+	if claims := runCommand(t, "", "verify", token); !strings.Contains(claims, `"sub": "ops"`) {
+		t.Fatalf("unexpected claims: %s", claims)
+	}
+	if err = run([]string{"verify", "-key", testKey, token}, nil, &bytes.Buffer{}); err == nil {
+		t.Fatal("expect error with wrong key")
+	}
+
+	//json格式的密钥环保持json格式，已存在的密钥ID不能重复添加
+	jsonPath := filepath.Join(t.TempDir(), "keyring.json")
+	if err = ioutil.WriteFile(jsonPath, []byte(`{"keys": {"k1": "MTIzNDU2Nzg5MDEyMzQ1Ng=="}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	runCommand(t, "", "genkey", "-keyring", jsonPath, "-id", "k2")
+	data, _ := ioutil.ReadFile(jsonPath)
+	if keyring, err = encipherment.ParseKeyring(data); err != nil || !bytes.HasPrefix(data, []byte("{")) ||
+		keyring.Primary() != "k1" || len(keyring.IDs()) != 2 {
+		t.Fatalf("unexpected keyring: %s, %v", data, err)
+	}
+	if err = run([]string{"genkey", "-keyring", path, "-id", "k1"}, nil, &bytes.Buffer{}); err == nil {
+		t.Fatal("expect error with duplicated key id")
+	}
+}
+
+func TestDerive(t *testing.T) {
+	output := runCommand(t, "passphrase\n", "derive", "-kdf", encipherment.KDFPbkdf2)
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected output: %s", output)
+	}
+	params := strings.TrimPrefix(lines[0], "params: ")
+	again := runCommand(t, "", "derive", "-passphrase", "passphrase", "-params", params)
+	if again != output {
+		t.Fatalf("expect %s, but got %s", output, again)
+	}
+
+	key := strings.TrimPrefix(lines[1], "key: ")
+	ciphertext := runCommand(t, "", "encrypt", "-key", key, "derived")
+	if plaintext := runCommand(t, ciphertext, "decrypt", "-key", key); plaintext != "derived" {
+		t.Fatalf("expect derived, but got %q", plaintext)
+	}
+}
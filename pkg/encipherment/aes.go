@@ -4,33 +4,23 @@ import (
 	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/subtle"
 	"encoding/base64"
-	"errors"
 	"fmt"
 )
 
+// AlgorithmAesCbc 旧版AES-CBC算法名称，与 AesEncrypt 的行为一致
+// 使用key的前16字节作为IV，相同明文得到相同密文，且没有完整性校验，仅用于兼容旧数据
+// 只能通过 GetCipher 直接使用，Encrypt、Decrypt 等信封格式的函数会拒绝该算法
+const AlgorithmAesCbc = "aes-cbc"
+
 // AesEncrypt 加密
 // key length must 16, 24, or 32 bytes to select
 func AesEncrypt(originalText string, key string) (string, error) {
-	// 转成字节数组
-	originalData := []byte(originalText)
-	keyData := []byte(key)
-
-	// 分组密钥
-	block, err := aes.NewCipher(keyData)
+	encrypted, err := cbcEncrypt([]byte(key), []byte(originalText))
 	if err != nil {
-		return "", errors.New(fmt.Sprintf("key 长度必须 16/24/32长度: %s", err.Error()))
+		return "", err
 	}
-	// 获取密钥快长度
-	blockSize := block.BlockSize()
-	// 补全码
-	origData := PKCS7Padding(originalData, blockSize)
-	// 加密模式
-	blockMode := cipher.NewCBCEncrypter(block, keyData[:blockSize])
-	// 创建数组
-	encrypted := make([]byte, len(origData))
-	// 加密
-	blockMode.CryptBlocks(encrypted, origData)
 	//使用RawURLEncoding 不要使用StdEncoding
 	//不要使用StdEncoding  放在url参数中回导致错误
 	return base64.RawURLEncoding.EncodeToString(encrypted), nil
@@ -38,6 +28,7 @@ func AesEncrypt(originalText string, key string) (string, error) {
 }
 
 // AesDecrypt aes解密
+// 密文或key不合法时会返回错误结果甚至panic，处理外部输入时请使用 AesDecryptWithError
 func AesDecrypt(ciphertext string, key string) string {
 	//使用RawURLEncoding 不要使用StdEncoding
 	//不要使用StdEncoding  放在url参数中回导致错误
@@ -45,9 +36,9 @@ func AesDecrypt(ciphertext string, key string) string {
 	k := []byte(key)
 
 	// 分组密钥
-	block, err := aes.NewCipher(k)
+	block, err := newAesBlock(k)
 	if err != nil {
-		panic(fmt.Sprintf("key 长度必须 16/24/32长度: %s", err.Error()))
+		panic(err.Error())
 	}
 	// 获取密钥块的长度
 	blockSize := block.BlockSize()
@@ -62,6 +53,85 @@ func AesDecrypt(ciphertext string, key string) string {
 	return string(orig)
 }
 
+// AesDecryptWithError aes解密，与 AesDecrypt 结果一致，但所有失败都以错误返回，不会panic
+// 错误可以通过 errors.Is 与 ErrInvalidEncoding、ErrInvalidKey、ErrInvalidBlockSize、ErrInvalidPadding 比较
+func AesDecryptWithError(ciphertext string, key string) (string, error) {
+	orig, err := decryptLegacy(ciphertext, []byte(key))
+	if err != nil {
+		return "", err
+	}
+	return string(orig), nil
+}
+
+// cbcEncrypt 旧版AES-CBC加密，IV为key的前16字节
+func cbcEncrypt(keyData, originalData []byte) ([]byte, error) {
+	// 分组密钥
+	block, err := newAesBlock(keyData)
+	if err != nil {
+		return nil, err
+	}
+	// 获取密钥快长度
+	blockSize := block.BlockSize()
+	// 补全码
+	origData := PKCS7Padding(append([]byte(nil), originalData...), blockSize)
+	// 加密模式
+	blockMode := cipher.NewCBCEncrypter(block, keyData[:blockSize])
+	// 创建数组
+	encrypted := make([]byte, len(origData))
+	// 加密
+	blockMode.CryptBlocks(encrypted, origData)
+	return encrypted, nil
+}
+
+// cbcDecrypt 旧版AES-CBC解密，所有失败都以错误返回
+func cbcDecrypt(k, decryptedByte []byte) ([]byte, error) {
+	// 分组密钥
+	block, err := newAesBlock(k)
+	if err != nil {
+		return nil, err
+	}
+	// 获取密钥块的长度
+	blockSize := block.BlockSize()
+	if len(decryptedByte) == 0 || len(decryptedByte)%blockSize != 0 {
+		return nil, ErrInvalidBlockSize
+	}
+	// 加密模式
+	blockMode := cipher.NewCBCDecrypter(block, k[:blockSize])
+	// 创建数组
+	orig := make([]byte, len(decryptedByte))
+	// 解密
+	blockMode.CryptBlocks(orig, decryptedByte)
+	// 去补全码
+	return PKCS7UnPaddingWithError(orig, blockSize)
+}
+
+// aesCbcCipher 旧版AES-CBC的 Cipher 实现，不支持附加数据，附加数据会被忽略
+// 没有完整性校验，不能用于信封格式，旧密文通过 AesDecrypt、DecryptAny 解密
+type aesCbcCipher struct{}
+
+func (aesCbcCipher) legacy() {}
+
+func (aesCbcCipher) Name() string {
+	return AlgorithmAesCbc
+}
+
+func (aesCbcCipher) Encrypt(key, plaintext, _ []byte) ([]byte, error) {
+	return cbcEncrypt(key, plaintext)
+}
+
+func (aesCbcCipher) Decrypt(key, payload, _ []byte) ([]byte, error) {
+	return cbcDecrypt(key, payload)
+}
+
+// newAesBlock 创建aes分组密钥，加密与解密共用
+func newAesBlock(key []byte) (cipher.Block, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: aes key length must be 16/24/32, got %d", ErrInvalidKey, len(key))
+	}
+	return block, nil
+}
+
 // PKCS7Padding 补码
 func PKCS7Padding(ciphertext []byte, blockSize int) []byte {
 	padding := blockSize - len(ciphertext)%blockSize
@@ -75,3 +145,25 @@ func PKCS7UnPadding(origData []byte) []byte {
 	unPadding := int(origData[length-1])
 	return origData[:(length - unPadding)]
 }
+
+// PKCS7UnPaddingWithError 去码，数据长度或补码不合法时返回错误
+// 补码的校验为常量时间，避免通过耗时差异泄露补码信息
+func PKCS7UnPaddingWithError(origData []byte, blockSize int) ([]byte, error) {
+	length := len(origData)
+	if blockSize <= 0 || length == 0 || length%blockSize != 0 {
+		return nil, ErrInvalidBlockSize
+	}
+	padding := origData[length-1]
+	unPadding := int(padding)
+	good := subtle.ConstantTimeLessOrEq(1, unPadding) & subtle.ConstantTimeLessOrEq(unPadding, blockSize)
+	// 始终检查最后一个分组的全部字节，补码范围内的字节必须都等于补码值
+	for i := 1; i <= blockSize; i++ {
+		inPadding := subtle.ConstantTimeLessOrEq(i, unPadding)
+		equal := subtle.ConstantTimeByteEq(origData[length-i], padding)
+		good &= subtle.ConstantTimeSelect(inPadding, equal, 1)
+	}
+	if good != 1 {
+		return nil, ErrInvalidPadding
+	}
+	return origData[:(length - unPadding)], nil
+}
@@ -1,6 +1,7 @@
 package encipherment
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 )
@@ -16,3 +17,59 @@ func TestAes(t *testing.T) {
 	decryptCode := AesDecrypt(encryptCode, key)
 	fmt.Println("解密结果：", decryptCode)
 }
+
+func TestAesDecryptWithError(t *testing.T) {
+	orig := "EV8mXRBtnPLVxtIhoxEA3vbTGDqUeBDlUUvu4Kds"
+	key := "launcherSoNBPlus"
+
+	encryptCode, err := AesEncrypt(orig, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decryptCode, err := AesDecryptWithError(encryptCode, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decryptCode != orig {
+		t.Fatal(decryptCode)
+	}
+
+	cases := []struct {
+		ciphertext string
+		key        string
+		expect     error
+	}{
+		{ciphertext: "not base64!", key: key, expect: ErrInvalidEncoding},
+		{ciphertext: encryptCode, key: "short", expect: ErrInvalidKey},
+		{ciphertext: "", key: key, expect: ErrInvalidBlockSize},
+		{ciphertext: encryptCode[:10], key: key, expect: ErrInvalidBlockSize},
+		{ciphertext: encryptCode, key: "launcherSoNBPluz", expect: ErrInvalidPadding},
+	}
+	for _, c := range cases {
+		if _, err = AesDecryptWithError(c.ciphertext, c.key); !errors.Is(err, c.expect) {
+			t.Fatalf("expect %v, got %v", c.expect, err)
+		}
+	}
+}
+
+func TestPKCS7UnPaddingWithError(t *testing.T) {
+	padded := PKCS7Padding([]byte("hello"), 8)
+	data, err := PKCS7UnPaddingWithError(padded, 8)
+	if err != nil || string(data) != "hello" {
+		t.Fatal(string(data), err)
+	}
+
+	bad := [][]byte{
+		{1, 2, 3, 4, 5, 6, 7, 0},
+		{1, 2, 3, 4, 5, 6, 7, 9},
+		{1, 2, 3, 4, 5, 6, 2, 3},
+	}
+	for _, b := range bad {
+		if _, err = PKCS7UnPaddingWithError(b, 8); err != ErrInvalidPadding {
+			t.Fatal(b, err)
+		}
+	}
+	if _, err = PKCS7UnPaddingWithError(nil, 8); err != ErrInvalidBlockSize {
+		t.Fatal(err)
+	}
+}
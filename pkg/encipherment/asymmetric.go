@@ -0,0 +1,186 @@
+package encipherment
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+)
+
+// ParsePrivateKey 解析PEM格式的私钥，支持 PKCS#1(RSA PRIVATE KEY)、SEC1(EC PRIVATE KEY)
+// 与 PKCS#8(PRIVATE KEY)，返回 *rsa.PrivateKey、*ecdsa.PrivateKey 或 ed25519.PrivateKey
+func ParsePrivateKey(pemData []byte) (crypto.Signer, error) {
+	block, err := decodePem(pemData)
+	if err != nil {
+		return nil, err
+	}
+	//解析失败时需要返回nil，不能返回包装了nil指针的接口
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return key, nil
+	case "EC PRIVATE KEY":
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return key, nil
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	}
+	return nil, fmt.Errorf("unsupported pem block type: %s", block.Type)
+}
+
+// ParsePublicKey 解析PEM格式的公钥，支持 PKIX(PUBLIC KEY)、PKCS#1(RSA PUBLIC KEY) 与证书(CERTIFICATE)
+// 返回 *rsa.PublicKey、*ecdsa.PublicKey 或 ed25519.PublicKey
+func ParsePublicKey(pemData []byte) (crypto.PublicKey, error) {
+	block, err := decodePem(pemData)
+	if err != nil {
+		return nil, err
+	}
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return key, nil
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	}
+	return nil, fmt.Errorf("unsupported pem block type: %s", block.Type)
+}
+
+// LoadPrivateKey 从PEM文件加载私钥，见 ParsePrivateKey
+func LoadPrivateKey(path string) (crypto.Signer, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrivateKey(data)
+}
+
+// LoadPublicKey 从PEM文件加载公钥，见 ParsePublicKey
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePublicKey(data)
+}
+
+func decodePem(pemData []byte) (*pem.Block, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no pem block found", ErrInvalidEncoding)
+	}
+	return block, nil
+}
+
+// RsaEncrypt 使用RSA-OAEP(SHA-256)加密，适用于给合作方加密少量敏感数据
+// 明文长度不能超过 公钥字节数-66，返回RawURLEncoding编码的密文
+func RsaEncrypt(plaintext []byte, publicKey *rsa.PublicKey) (string, error) {
+	encrypted, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, plaintext, nil)
+	if err != nil {
+		return "", err
+	}
+	//使用RawURLEncoding 不要使用StdEncoding
+	return base64.RawURLEncoding.EncodeToString(encrypted), nil
+}
+
+// RsaDecrypt 解密 RsaEncrypt 生成的密文
+func RsaDecrypt(ciphertext string, privateKey *rsa.PrivateKey) ([]byte, error) {
+	encrypted, err := base64.RawURLEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidEncoding, err.Error())
+	}
+	return rsa.DecryptOAEP(sha256.New(), rand.Reader, privateKey, encrypted, nil)
+}
+
+// SignMessage 签名，返回RawURLEncoding编码的签名
+// RSA 使用 RSA-PSS(SHA-256)，ECDSA 按曲线使用 SHA-256/384/512 并输出ASN.1格式签名，Ed25519 直接对消息签名
+func SignMessage(privateKey crypto.Signer, message []byte) (string, error) {
+	var signature []byte
+	var err error
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256(message)
+		signature, err = rsa.SignPSS(rand.Reader, key, crypto.SHA256, digest[:],
+			&rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case *ecdsa.PrivateKey:
+		signature, err = ecdsa.SignASN1(rand.Reader, key, ecdsaDigest(key.Curve, message))
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(key, message)
+	default:
+		return "", fmt.Errorf("unsupported private key type %T", privateKey)
+	}
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// VerifyMessage 校验 SignMessage 生成的签名，签名不正确时返回 ErrInvalidSignature
+func VerifyMessage(publicKey crypto.PublicKey, message []byte, signature string) error {
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidEncoding, err.Error())
+	}
+	valid := false
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(message)
+		valid = rsa.VerifyPSS(key, crypto.SHA256, digest[:], sig,
+			&rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto}) == nil
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(key, ecdsaDigest(key.Curve, message), sig)
+	case ed25519.PublicKey:
+		valid = len(key) == ed25519.PublicKeySize && ed25519.Verify(key, message, sig)
+	default:
+		return fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ecdsaDigest 按曲线位数选择摘要算法
+func ecdsaDigest(curve elliptic.Curve, message []byte) []byte {
+	hash := crypto.SHA256
+	switch bits := curve.Params().BitSize; {
+	case bits > 384:
+		hash = crypto.SHA512
+	case bits > 256:
+		hash = crypto.SHA384
+	}
+	h := hash.New()
+	h.Write(message)
+	return h.Sum(nil)
+}
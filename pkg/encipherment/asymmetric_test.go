@@ -0,0 +1,93 @@
+package encipherment
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func TestRsaEncrypt(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privatePem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	publicPem := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)})
+
+	privateKey, err := ParsePrivateKey(privatePem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := ParsePublicKey(publicPem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext, err := RsaEncrypt([]byte("hello"), publicKey.(*rsa.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := RsaDecrypt(ciphertext, privateKey.(*rsa.PrivateKey))
+	if err != nil || string(plaintext) != "hello" {
+		t.Fatal(string(plaintext), err)
+	}
+}
+
+func TestSignMessage(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	for _, key := range []crypto.Signer{rsaKey, ecKey, edKey} {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privateKey, err := ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err = x509.MarshalPKIXPublicKey(key.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		publicKey, err := ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		message := []byte("partner callback body")
+		signature, err := SignMessage(privateKey, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = VerifyMessage(publicKey, message, signature); err != nil {
+			t.Fatalf("%T: %v", key, err)
+		}
+		if err = VerifyMessage(publicKey, []byte("tampered"), signature); err != ErrInvalidSignature {
+			t.Fatalf("%T: %v", key, err)
+		}
+	}
+
+	if _, err := ParsePrivateKey([]byte("not pem")); err == nil {
+		t.Fatal("expected error for invalid pem")
+	}
+
+	//解析失败时返回的接口必须为nil
+	for _, blockType := range []string{"RSA PRIVATE KEY", "EC PRIVATE KEY"} {
+		key, err := ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: []byte("bad")}))
+		if err == nil || key != nil {
+			t.Fatalf("%s: expect nil key and error, but got %v, %v", blockType, key, err)
+		}
+	}
+	publicKey, err := ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: []byte("bad")}))
+	if err == nil || publicKey != nil {
+		t.Fatalf("expect nil key and error, but got %v, %v", publicKey, err)
+	}
+}
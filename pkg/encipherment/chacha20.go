@@ -0,0 +1,33 @@
+package encipherment
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// AlgorithmChaCha20Poly1305 ChaCha20-Poly1305 算法名称，key 长度必须为32字节
+const AlgorithmChaCha20Poly1305 = "chacha20-poly1305"
+
+// chaCha20Poly1305Cipher ChaCha20-Poly1305 的 Cipher 实现，每次加密使用随机nonce
+type chaCha20Poly1305Cipher struct{}
+
+func (chaCha20Poly1305Cipher) Name() string {
+	return AlgorithmChaCha20Poly1305
+}
+
+func (chaCha20Poly1305Cipher) Encrypt(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: chacha20-poly1305 key length must be 32, got %d", ErrInvalidKey, len(key))
+	}
+	return aeadSeal(aead, plaintext, additionalData)
+}
+
+func (chaCha20Poly1305Cipher) Decrypt(key, payload, additionalData []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: chacha20-poly1305 key length must be 32, got %d", ErrInvalidKey, len(key))
+	}
+	return aeadOpen(aead, payload, additionalData)
+}
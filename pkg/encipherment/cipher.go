@@ -0,0 +1,136 @@
+package encipherment
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Cipher 对称加密算法，通过 RegisterCipher 注册后，可以按名称选择
+// 信封格式的算法名称来自密文头部，因此注册的算法必须是带完整性校验的AEAD算法
+type Cipher interface {
+	// Name 算法名称，写入密文头部，解密时据此选择算法
+	Name() string
+	// Encrypt 加密，返回算法相关的payload，例如 nonce || ciphertext || tag
+	Encrypt(key, plaintext, additionalData []byte) ([]byte, error)
+	// Decrypt 解密 Encrypt 返回的payload
+	Decrypt(key, payload, additionalData []byte) ([]byte, error)
+}
+
+// legacyCipher 没有完整性校验的旧版算法，只能通过 GetCipher 直接使用，不能用于信封格式
+// 否则篡改密文头部的算法名称即可绕过完整性校验
+type legacyCipher interface {
+	legacy()
+}
+
+var (
+	ciphersLock sync.RWMutex
+	ciphers     = make(map[string]Cipher)
+)
+
+func init() {
+	RegisterCipher(aesGcmCipher{})
+	RegisterCipher(aesCbcCipher{})
+	RegisterCipher(chaCha20Poly1305Cipher{})
+	RegisterCipher(aesSivCipher{})
+}
+
+// RegisterCipher 注册算法，名称重复或为空时panic
+func RegisterCipher(c Cipher) {
+	ciphersLock.Lock()
+	defer ciphersLock.Unlock()
+	if c == nil || c.Name() == "" {
+		panic("encipherment: register nil or unnamed cipher")
+	}
+	if _, ok := ciphers[c.Name()]; ok {
+		panic("encipherment: register cipher twice: " + c.Name())
+	}
+	ciphers[c.Name()] = c
+}
+
+// GetCipher 按名称获取已注册的算法
+func GetCipher(name string) (Cipher, error) {
+	ciphersLock.RLock()
+	defer ciphersLock.RUnlock()
+	c, ok := ciphers[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported algorithm: %s", name)
+	}
+	return c, nil
+}
+
+// Ciphers 返回可用于信封格式的算法名称，不包含旧版的 AlgorithmAesCbc
+func Ciphers() []string {
+	ciphersLock.RLock()
+	defer ciphersLock.RUnlock()
+	names := make([]string, 0, len(ciphers))
+	for name, c := range ciphers {
+		if _, ok := c.(legacyCipher); ok {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Encrypt 使用指定算法加密，算法名称写入信封头部
+// 算法名称可以来自配置，切换算法后，已有密文仍可通过 Decrypt 解密
+func Encrypt(algorithm string, plaintext, key, additionalData []byte) (string, error) {
+	return sealEnvelope(&envelope{algorithm: algorithm}, key, plaintext, additionalData)
+}
+
+// Decrypt 解密信封，按信封头部的算法名称自动选择算法
+func Decrypt(ciphertext string, key, additionalData []byte) ([]byte, error) {
+	e, err := parseEnvelope(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.wrappedKey) > 0 {
+		return nil, ErrWrappedKey
+	}
+	return openEnvelope(e, key, additionalData)
+}
+
+// sealEnvelope 加密明文并写入信封的payload，返回编码后的信封
+func sealEnvelope(e *envelope, key, plaintext, additionalData []byte) (string, error) {
+	if err := sealPayload(e, key, plaintext, additionalData); err != nil {
+		return "", err
+	}
+	return e.String(), nil
+}
+
+// envelopeCipher 获取信封格式使用的算法，拒绝没有完整性校验的旧版算法
+func envelopeCipher(name string) (Cipher, error) {
+	c, err := GetCipher(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := c.(legacyCipher); ok {
+		return nil, fmt.Errorf("algorithm %s is not authenticated and can not be used in envelope", name)
+	}
+	return c, nil
+}
+
+// sealPayload 加密明文并写入信封的payload
+func sealPayload(e *envelope, key, plaintext, additionalData []byte) error {
+	c, err := envelopeCipher(e.algorithm)
+	if err != nil {
+		return err
+	}
+	payload, err := c.Encrypt(key, plaintext, e.additionalData(additionalData))
+	if err != nil {
+		return err
+	}
+	e.payload = payload
+	return nil
+}
+
+// openEnvelope 解密信封的payload
+func openEnvelope(e *envelope, key, additionalData []byte) ([]byte, error) {
+	c, err := envelopeCipher(e.algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return c.Decrypt(key, e.payload, e.additionalData(additionalData))
+}
@@ -0,0 +1,120 @@
+package encipherment
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestCiphers(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	orig := []byte("EV8mXRBtnPLVxtIhoxEA3vbTGDqUeBDlUUvu4Kds")
+	for _, name := range Ciphers() {
+		ciphertext, err := Encrypt(name, orig, key, []byte("ad"))
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		e, _ := parseEnvelope(ciphertext)
+		if e.algorithm != name {
+			t.Fatal(name, e.algorithm)
+		}
+		plaintext, err := Decrypt(ciphertext, key, []byte("ad"))
+		if err != nil || string(plaintext) != string(orig) {
+			t.Fatal(name, string(plaintext), err)
+		}
+	}
+
+	//旧版AES-CBC的payload与 AesEncrypt 一致
+	c, err := GetCipher(AlgorithmAesCbc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, _ := c.Encrypt(key, orig, nil)
+	legacy, _ := AesEncrypt(string(orig), string(key))
+	if legacy != base64.RawURLEncoding.EncodeToString(payload) {
+		t.Fatal(legacy)
+	}
+
+	if _, err := Encrypt("unknown", orig, key, nil); err == nil {
+		t.Fatal("expected error for unknown algorithm")
+	}
+}
+
+func TestLegacyCipherNotInEnvelope(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	if _, err := Encrypt(AlgorithmAesCbc, []byte("hello"), key, nil); err == nil {
+		t.Fatal("expected error when sealing with aes-cbc")
+	}
+	if err := NewKeyring().SetAlgorithm(AlgorithmAesCbc); err == nil {
+		t.Fatal("expected error when keyring uses aes-cbc")
+	}
+	for _, name := range Ciphers() {
+		if name == AlgorithmAesCbc {
+			t.Fatal("aes-cbc listed as envelope algorithm")
+		}
+	}
+
+	//将头部的算法改为aes-cbc，并替换为没有完整性校验的payload
+	sealed, err := Seal([]byte("hello"), key, []byte("ad"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, _ := parseEnvelope(sealed)
+	e.algorithm = AlgorithmAesCbc
+	e.payload, _ = cbcEncrypt(key, []byte("forged"))
+	tampered := e.String()
+	if _, err = Open(tampered, key, []byte("ad")); err == nil {
+		t.Fatal("expected error when opening tampered aes-cbc envelope")
+	}
+	if _, err = Decrypt(tampered, key, nil); err == nil {
+		t.Fatal("expected error when decrypting tampered aes-cbc envelope")
+	}
+	keyring := NewKeyring()
+	_ = keyring.Add("k1", key)
+	e.keyID = "k1"
+	if _, err = keyring.Open(e.String(), nil); err == nil {
+		t.Fatal("expected error when keyring opens tampered aes-cbc envelope")
+	}
+}
+
+func TestKeyringAlgorithm(t *testing.T) {
+	keyring := NewKeyring()
+	_ = keyring.Add("k1", []byte("0123456789abcdef0123456789abcdef"))
+	old, _ := keyring.Seal([]byte("hello"), nil)
+
+	if err := keyring.SetAlgorithm(AlgorithmChaCha20Poly1305); err != nil {
+		t.Fatal(err)
+	}
+	upgraded, changed, err := keyring.ReEncrypt(old, nil)
+	if err != nil || !changed {
+		t.Fatal(changed, err)
+	}
+	e, _ := parseEnvelope(upgraded)
+	if e.algorithm != AlgorithmChaCha20Poly1305 {
+		t.Fatal(e.algorithm)
+	}
+	plaintext, err := keyring.Open(upgraded, nil)
+	if err != nil || string(plaintext) != "hello" {
+		t.Fatal(string(plaintext), err)
+	}
+
+	//主密钥的长度不满足算法要求时不能切换算法或主密钥
+	if err = keyring.Rotate("k2", []byte("0123456789abcdef")); !errors.Is(err, ErrInvalidKey) || keyring.Primary() != "k1" {
+		t.Fatal(keyring.Primary(), err)
+	}
+	_ = keyring.SetAlgorithm(AlgorithmAesGcm)
+	_ = keyring.Rotate("k2", []byte("0123456789abcdef"))
+	for _, algorithm := range []string{AlgorithmChaCha20Poly1305, AlgorithmAesSiv} {
+		if err = keyring.SetAlgorithm(algorithm); !errors.Is(err, ErrInvalidKey) || keyring.Algorithm() != AlgorithmAesGcm {
+			t.Fatal(algorithm, err)
+		}
+	}
+	_ = keyring.SetPrimary("k1")
+	_ = keyring.SetAlgorithm(AlgorithmChaCha20Poly1305)
+	if err = keyring.SetPrimary("k2"); !errors.Is(err, ErrInvalidKey) || keyring.Primary() != "k1" {
+		t.Fatal(keyring.Primary(), err)
+	}
+	if _, err = keyring.Seal([]byte("hello"), nil); err != nil {
+		t.Fatal(err)
+	}
+}
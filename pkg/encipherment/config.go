@@ -0,0 +1,286 @@
+package encipherment
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	encPrefix = "ENC("
+	encSuffix = ")"
+	// strTag 字符串的yaml标签，字符串加密后不保存标签
+	strTag = "!!str"
+)
+
+// LoadConfig 读取yaml或json配置，将所有 ENC(<密文>) 格式的值解密后解码到v
+// 密文由 cipher 解密，可以是 *Keyring 或 NewFieldCipher 构建的单密钥 FieldCipher
+// json配置按json标签解码，yaml配置按yaml标签解码
+func LoadConfig(data []byte, v interface{}, cipher FieldCipher) error {
+	root, err := parseConfig(data)
+	if err != nil {
+		return err
+	}
+	if err = decryptNode(root, cipher, ""); err != nil {
+		return err
+	}
+	if !isJSON(data) {
+		return root.Decode(v)
+	}
+	buf := &bytes.Buffer{}
+	if err = encodeJSONNode(buf, root); err != nil {
+		return err
+	}
+	return json.Unmarshal(buf.Bytes(), v)
+}
+
+// LoadConfigFile 读取配置文件，见 LoadConfig
+func LoadConfigFile(path string, v interface{}, cipher FieldCipher) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return LoadConfig(data, v, cipher)
+}
+
+// EncryptConfig 将配置中指定路径的明文值改写为 ENC(<密文>)，返回改写后的配置
+// 路径使用 '.' 分隔，数组下标使用数字，'*' 匹配任意键或下标，例如 database.password、clouds.*.sk
+// yaml配置会保留注释与键的顺序，已经是 ENC(...) 的值不会重复加密，路径不存在时返回错误
+// 数字、布尔等非字符串的值会在 ENC(...) 中保存原始类型，例如 ENC(!!int <密文>)，解密后恢复原始类型
+func EncryptConfig(data []byte, paths []string, cipher FieldCipher) ([]byte, error) {
+	root, err := parseConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		nodes := findNodes(root, strings.Split(path, "."))
+		if len(nodes) == 0 {
+			return nil, fmt.Errorf("config path %s not found", path)
+		}
+		for _, node := range nodes {
+			if node.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("config path %s is not a scalar value", path)
+			}
+			if _, ok := ParseEncValue(node.Value); ok {
+				continue
+			}
+			sealed, err := cipher.Seal([]byte(node.Value), nil)
+			if err != nil {
+				return nil, fmt.Errorf("encrypt config path %s: %w", path, err)
+			}
+			node.Value = formatEncValue(sealed, node.ShortTag())
+			node.Tag = strTag
+			node.Style = 0
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if isJSON(data) {
+		if err = encodeJSONNode(buf, root); err != nil {
+			return nil, err
+		}
+		out := &bytes.Buffer{}
+		if err = json.Indent(out, buf.Bytes(), "", "  "); err != nil {
+			return nil, err
+		}
+		out.WriteString("\n")
+		return out.Bytes(), nil
+	}
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+	if err = encoder.Encode(root); err != nil {
+		return nil, err
+	}
+	if err = encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// EncryptConfigFile 原地改写配置文件，见 EncryptConfig
+func EncryptConfigFile(path string, paths []string, cipher FieldCipher) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	out, err := EncryptConfig(data, paths, cipher)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, out, info.Mode())
+}
+
+func parseConfig(data []byte) (*yaml.Node, error) {
+	root := &yaml.Node{}
+	if err := yaml.Unmarshal(data, root); err != nil {
+		return nil, err
+	}
+	if root.Kind == 0 {
+		return nil, fmt.Errorf("config is empty")
+	}
+	return root, nil
+}
+
+// isJSON 以 '{' 或 '[' 开头的配置按json处理
+func isJSON(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
+
+// FormatEncValue 将密文包装为 ENC(<密文>)
+func FormatEncValue(ciphertext string) string {
+	return encPrefix + ciphertext + encSuffix
+}
+
+// ParseEncValue 提取 ENC(<密文>) 中的密文，值不是该格式时返回false
+// EncryptConfig 为非字符串的值保存的类型标签会被忽略
+func ParseEncValue(value string) (string, bool) {
+	ciphertext, _, ok := parseEncValue(value)
+	return ciphertext, ok
+}
+
+// formatEncValue 将密文包装为 ENC(<密文>)，非字符串的值包装为 ENC(<标签> <密文>)
+// 密文不包含空格，yaml标签也不包含空格，因此可以按空格拆分
+func formatEncValue(ciphertext, tag string) string {
+	if tag == "" || tag == strTag {
+		return FormatEncValue(ciphertext)
+	}
+	return FormatEncValue(tag + " " + ciphertext)
+}
+
+// parseEncValue 提取 ENC(...) 中的密文与yaml标签，没有标签时为 !!str
+func parseEncValue(value string) (ciphertext, tag string, ok bool) {
+	if !strings.HasPrefix(value, encPrefix) || !strings.HasSuffix(value, encSuffix) {
+		return "", "", false
+	}
+	ciphertext = value[len(encPrefix) : len(value)-len(encSuffix)]
+	if index := strings.LastIndex(ciphertext, " "); index >= 0 {
+		return ciphertext[index+1:], ciphertext[:index], true
+	}
+	return ciphertext, strTag, true
+}
+
+// decryptNode 递归解密所有 ENC(...) 值
+func decryptNode(node *yaml.Node, cipher FieldCipher, path string) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		ciphertext, tag, ok := parseEncValue(node.Value)
+		if !ok {
+			return nil
+		}
+		plaintext, err := cipher.Open(ciphertext, nil)
+		if err != nil {
+			return fmt.Errorf("decrypt config path %s: %w", path, err)
+		}
+		node.Value = string(plaintext)
+		node.Tag = tag
+		node.Style = 0
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := decryptNode(node.Content[i+1], cipher, joinPath(path, node.Content[i].Value)); err != nil {
+				return err
+			}
+		}
+	default:
+		for i, child := range node.Content {
+			childPath := path
+			if node.Kind == yaml.SequenceNode {
+				childPath = joinPath(path, strconv.Itoa(i))
+			}
+			if err := decryptNode(child, cipher, childPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// findNodes 按路径查找节点
+func findNodes(node *yaml.Node, path []string) []*yaml.Node {
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+		return findNodes(node.Content[0], path)
+	}
+	if len(path) == 0 {
+		return []*yaml.Node{node}
+	}
+	key, rest := path[0], path[1:]
+	var nodes []*yaml.Node
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if key == "*" || node.Content[i].Value == key {
+				nodes = append(nodes, findNodes(node.Content[i+1], rest)...)
+			}
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			if key == "*" || strconv.Itoa(i) == key {
+				nodes = append(nodes, findNodes(child, rest)...)
+			}
+		}
+	}
+	return nodes
+}
+
+// encodeJSONNode 将节点编码为json，保留键的顺序
+func encodeJSONNode(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return encodeJSONNode(buf, node.Content[0])
+	case yaml.MappingNode:
+		buf.WriteString("{")
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			key, _ := json.Marshal(node.Content[i].Value)
+			buf.Write(key)
+			buf.WriteString(":")
+			if err := encodeJSONNode(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("}")
+	case yaml.SequenceNode:
+		buf.WriteString("[")
+		for i, child := range node.Content {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			if err := encodeJSONNode(buf, child); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("]")
+	case yaml.ScalarNode:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	case yaml.AliasNode:
+		return encodeJSONNode(buf, node.Alias)
+	}
+	return nil
+}
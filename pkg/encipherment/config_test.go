@@ -0,0 +1,117 @@
+package encipherment
+
+import (
+	"strings"
+	"testing"
+)
+
+type appConfig struct {
+	Name     string `json:"name" yaml:"name"`
+	Database struct {
+		Host     string `json:"host" yaml:"host"`
+		Password string `json:"password" yaml:"password"`
+	} `json:"database" yaml:"database"`
+	Clouds []struct {
+		Ak string `json:"ak" yaml:"ak"`
+		Sk string `json:"sk" yaml:"sk"`
+	} `json:"clouds" yaml:"clouds"`
+}
+
+func TestEncryptConfigYAML(t *testing.T) {
+	keyring := NewKeyring()
+	_ = keyring.Add("k1", []byte("launcherSoNBPlus"))
+	data := []byte(`# 服务配置
+name: idp
+database:
+  host: 127.0.0.1
+  # 数据库密码
+  password: p@ssw0rd
+clouds:
+  - ak: ak1
+    sk: sk1
+  - ak: ak2
+    sk: sk2
+`)
+	encrypted, err := EncryptConfig(data, []string{"database.password", "clouds.*.sk"}, keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(encrypted)
+	if strings.Contains(content, "p@ssw0rd") || strings.Contains(content, "sk1") ||
+		!strings.Contains(content, "# 数据库密码") || !strings.Contains(content, "password: ENC(v1.") {
+		t.Fatal(content)
+	}
+	//重复执行不会重复加密
+	again, err := EncryptConfig(encrypted, []string{"database.password"}, keyring)
+	if err != nil || string(again) != content {
+		t.Fatal(string(again), err)
+	}
+
+	config := appConfig{}
+	if err = LoadConfig(encrypted, &config, keyring); err != nil {
+		t.Fatal(err)
+	}
+	if config.Database.Password != "p@ssw0rd" || config.Clouds[1].Sk != "sk2" || config.Clouds[0].Ak != "ak1" {
+		t.Fatal(config)
+	}
+
+	if _, err = EncryptConfig(data, []string{"database.missing"}, keyring); err == nil {
+		t.Fatal("expected error for missing path")
+	}
+}
+
+func TestEncryptConfigJSON(t *testing.T) {
+	cipher := NewFieldCipher([]byte("launcherSoNBPlus"))
+	data := []byte(`{"name": "idp", "database": {"host": "127.0.0.1", "password": "p@ssw0rd", "port": 3306}}`)
+	encrypted, err := EncryptConfig(data, []string{"database.password"}, cipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isJSON(encrypted) || strings.Contains(string(encrypted), "p@ssw0rd") ||
+		!strings.Contains(string(encrypted), `"port": 3306`) {
+		t.Fatal(string(encrypted))
+	}
+	config := appConfig{}
+	if err = LoadConfig(encrypted, &config, cipher); err != nil {
+		t.Fatal(err)
+	}
+	if config.Database.Password != "p@ssw0rd" || config.Name != "idp" {
+		t.Fatal(config)
+	}
+}
+
+func TestEncryptConfigTypes(t *testing.T) {
+	keyring := NewKeyring()
+	_ = keyring.Add("k1", []byte("launcherSoNBPlus"))
+	type typedConfig struct {
+		Port     int     `json:"port" yaml:"port"`
+		Debug    bool    `json:"debug" yaml:"debug"`
+		Ratio    float64 `json:"ratio" yaml:"ratio"`
+		Password string  `json:"password" yaml:"password"`
+	}
+	paths := []string{"port", "debug", "ratio", "password"}
+	for _, data := range []string{"port: 8080\ndebug: true\nratio: 0.5\npassword: \"123456\"\n",
+		`{"port": 8080, "debug": true, "ratio": 0.5, "password": "123456"}`} {
+		encrypted, err := EncryptConfig([]byte(data), paths, keyring)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content := string(encrypted)
+		if !strings.Contains(content, "ENC(!!int v1.") || !strings.Contains(content, "ENC(!!bool v1.") ||
+			strings.Contains(content, "8080") || strings.Contains(content, "!!str") {
+			t.Fatal(content)
+		}
+		config := typedConfig{}
+		if err = LoadConfig(encrypted, &config, keyring); err != nil {
+			t.Fatal(content, err)
+		}
+		if config.Port != 8080 || !config.Debug || config.Ratio != 0.5 || config.Password != "123456" {
+			t.Fatal(config)
+		}
+	}
+
+	//ParseEncValue 忽略类型标签
+	if ciphertext, ok := ParseEncValue("ENC(!!int v1.abc)"); !ok || ciphertext != "v1.abc" {
+		t.Fatal(ciphertext, ok)
+	}
+}
@@ -0,0 +1,160 @@
+package encipherment
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// Encoding 密文的输出编码
+type Encoding string
+
+const (
+	// EncodingRaw 不编码，直接输出二进制，适用于文件分块、protobuf 等二进制载荷
+	EncodingRaw Encoding = "raw"
+	// EncodingStdBase64 标准base64编码，带填充
+	EncodingStdBase64 Encoding = "base64"
+	// EncodingURLBase64 url安全的base64编码，不带填充，与 AesEncrypt、Seal 等字符串接口一致
+	EncodingURLBase64 Encoding = "base64url"
+	// EncodingHex 十六进制编码
+	EncodingHex Encoding = "hex"
+)
+
+// Encode 编码，编码方式不支持时返回错误
+func (e Encoding) Encode(src []byte) ([]byte, error) {
+	switch e {
+	case EncodingRaw:
+		return append([]byte(nil), src...), nil
+	case EncodingStdBase64:
+		dst := make([]byte, base64.StdEncoding.EncodedLen(len(src)))
+		base64.StdEncoding.Encode(dst, src)
+		return dst, nil
+	case EncodingURLBase64:
+		dst := make([]byte, base64.RawURLEncoding.EncodedLen(len(src)))
+		base64.RawURLEncoding.Encode(dst, src)
+		return dst, nil
+	case EncodingHex:
+		dst := make([]byte, hex.EncodedLen(len(src)))
+		hex.Encode(dst, src)
+		return dst, nil
+	}
+	return nil, fmt.Errorf("unsupported encoding %q", string(e))
+}
+
+// Decode 解码，数据不合法时返回 ErrInvalidEncoding
+func (e Encoding) Decode(src []byte) ([]byte, error) {
+	var dst []byte
+	var n int
+	var err error
+	switch e {
+	case EncodingRaw:
+		return append([]byte(nil), src...), nil
+	case EncodingStdBase64:
+		dst = make([]byte, base64.StdEncoding.DecodedLen(len(src)))
+		n, err = base64.StdEncoding.Decode(dst, src)
+	case EncodingURLBase64:
+		dst = make([]byte, base64.RawURLEncoding.DecodedLen(len(src)))
+		n, err = base64.RawURLEncoding.Decode(dst, src)
+	case EncodingHex:
+		dst = make([]byte, hex.DecodedLen(len(src)))
+		n, err = hex.Decode(dst, src)
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q", string(e))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidEncoding, err.Error())
+	}
+	return dst[:n], nil
+}
+
+// BytesOptions EncryptBytes/DecryptBytes 的选项，nil 与零值均使用默认值
+type BytesOptions struct {
+	// Algorithm 信封格式使用的算法，默认为 AlgorithmAesGcm，解密时按信封头部选择算法
+	Algorithm string
+	// Format 密文格式，加密时默认为 FormatEnvelope
+	// 解密时为空则按是否以信封前缀开头自动识别，二进制的旧格式密文可能恰好以前缀开头，此时应明确指定
+	Format Format
+	// Encoding 密文编码，默认为 EncodingRaw
+	Encoding Encoding
+	// AdditionalData 附加数据，只用于信封格式
+	AdditionalData []byte
+}
+
+func (o *BytesOptions) withDefaults() BytesOptions {
+	options := BytesOptions{}
+	if o != nil {
+		options = *o
+	}
+	if options.Algorithm == "" {
+		options.Algorithm = AlgorithmAesGcm
+	}
+	if options.Encoding == "" {
+		options.Encoding = EncodingRaw
+	}
+	return options
+}
+
+// EncryptBytes 加密并按选项编码，返回字节形式的密文
+// key length must 16, 24, or 32 bytes to select
+// 信封格式输出 v1. || 编码(信封)，旧格式输出 编码(AES-CBC密文)
+// 因此 EncodingURLBase64 的信封格式与 Encrypt 输出一致，旧格式与 AesEncrypt 输出一致
+func EncryptBytes(plaintext, key []byte, options *BytesOptions) ([]byte, error) {
+	o := options.withDefaults()
+	switch o.Format {
+	case FormatLegacy:
+		encrypted, err := cbcEncrypt(key, plaintext)
+		if err != nil {
+			return nil, err
+		}
+		return o.Encoding.Encode(encrypted)
+	case FormatEnvelope, "":
+		e := &envelope{algorithm: o.Algorithm}
+		if err := sealPayload(e, key, plaintext, o.AdditionalData); err != nil {
+			return nil, err
+		}
+		body, err := o.Encoding.Encode(e.body())
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(envelopePrefix), body...), nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", string(o.Format))
+}
+
+// DecryptBytes 解密 EncryptBytes 生成的密文，Encoding 与 Format 需要与加密时一致
+func DecryptBytes(ciphertext, key []byte, options *BytesOptions) ([]byte, error) {
+	o := options.withDefaults()
+	format := o.Format
+	if format == "" {
+		format = FormatLegacy
+		if bytes.HasPrefix(ciphertext, []byte(envelopePrefix)) {
+			format = FormatEnvelope
+		}
+	}
+	switch format {
+	case FormatLegacy:
+		encrypted, err := o.Encoding.Decode(ciphertext)
+		if err != nil {
+			return nil, err
+		}
+		return cbcDecrypt(key, encrypted)
+	case FormatEnvelope:
+		if !bytes.HasPrefix(ciphertext, []byte(envelopePrefix)) {
+			return nil, ErrInvalidEnvelope
+		}
+		body, err := o.Encoding.Decode(ciphertext[len(envelopePrefix):])
+		if err != nil {
+			return nil, err
+		}
+		e, err := parseEnvelopeBody(body)
+		if err != nil {
+			return nil, err
+		}
+		if len(e.wrappedKey) > 0 {
+			return nil, ErrWrappedKey
+		}
+		return openEnvelope(e, key, o.AdditionalData)
+	}
+	return nil, fmt.Errorf("unsupported format %q", string(format))
+}
@@ -0,0 +1,83 @@
+package encipherment
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestEncryptBytes(t *testing.T) {
+	key := []byte("1234567890123456")
+	plaintext := []byte{0x00, 0xff, 0x10, 'a', 'b', 'c'}
+	encodings := []Encoding{"", EncodingRaw, EncodingStdBase64, EncodingURLBase64, EncodingHex}
+	formats := []Format{FormatEnvelope, FormatLegacy}
+	for _, encoding := range encodings {
+		for _, format := range formats {
+			options := &BytesOptions{Format: format, Encoding: encoding}
+			ciphertext, err := EncryptBytes(plaintext, key, options)
+			if err != nil {
+				t.Fatalf("%s/%s: %v", format, encoding, err)
+			}
+			decrypted, err := DecryptBytes(ciphertext, key, options)
+			if err != nil {
+				t.Fatalf("%s/%s: %v", format, encoding, err)
+			}
+			if !bytes.Equal(decrypted, plaintext) {
+				t.Fatalf("%s/%s: expect %v, but got %v", format, encoding, plaintext, decrypted)
+			}
+		}
+	}
+
+	//不指定格式时自动识别
+	ciphertext, err := EncryptBytes(plaintext, key, &BytesOptions{Encoding: EncodingHex, AdditionalData: []byte("id")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = DecryptBytes(ciphertext, key, &BytesOptions{Encoding: EncodingHex}); err == nil {
+		t.Fatal("expect error without additional data")
+	}
+	decrypted, err := DecryptBytes(ciphertext, key, &BytesOptions{Encoding: EncodingHex, AdditionalData: []byte("id")})
+	if err != nil || !bytes.Equal(decrypted, plaintext) {
+		t.Fatalf("expect %v, but got %v, %v", plaintext, decrypted, err)
+	}
+}
+
+func TestEncryptBytesCompatible(t *testing.T) {
+	key := "1234567890123456"
+	legacy, err := EncryptBytes([]byte("hello"), []byte(key), &BytesOptions{Format: FormatLegacy, Encoding: EncodingURLBase64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := AesEncrypt("hello", key)
+	if string(legacy) != expected {
+		t.Fatalf("expect %s, but got %s", expected, legacy)
+	}
+	if plaintext, err := AesDecryptWithError(string(legacy), key); err != nil || plaintext != "hello" {
+		t.Fatalf("expect hello, but got %s, %v", plaintext, err)
+	}
+
+	envelope, err := EncryptBytes([]byte("hello"), []byte(key), &BytesOptions{Encoding: EncodingURLBase64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext, err := Open(string(envelope), []byte(key), nil); err != nil || string(plaintext) != "hello" {
+		t.Fatalf("expect hello, but got %s, %v", plaintext, err)
+	}
+	sealed, _ := Seal([]byte("hello"), []byte(key), nil)
+	if plaintext, err := DecryptBytes([]byte(sealed), []byte(key), &BytesOptions{Encoding: EncodingURLBase64}); err != nil || string(plaintext) != "hello" {
+		t.Fatalf("expect hello, but got %s, %v", plaintext, err)
+	}
+}
+
+func TestEncodingErrors(t *testing.T) {
+	key := []byte("1234567890123456")
+	if _, err := DecryptBytes([]byte("zz"), key, &BytesOptions{Format: FormatLegacy, Encoding: EncodingHex}); !errors.Is(err, ErrInvalidEncoding) {
+		t.Fatalf("expect ErrInvalidEncoding, but got %v", err)
+	}
+	if _, err := EncryptBytes([]byte("a"), key, &BytesOptions{Encoding: "base32"}); err == nil {
+		t.Fatal("expect error with unsupported encoding")
+	}
+	if _, err := DecryptBytes([]byte("abc"), key, &BytesOptions{Format: FormatEnvelope}); !errors.Is(err, ErrInvalidEnvelope) {
+		t.Fatalf("expect ErrInvalidEnvelope, but got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package encipherment
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// envelopePrefix 信封格式的版本前缀
+// 旧的 AesEncrypt 密文只包含 RawURLEncoding 字符，不会出现 '.'，以此区分两种格式
+const envelopePrefix = "v1."
+
+// envelope 带版本的密文信封
+// 编码后的格式：v1.<base64url(header || payload)>
+// header：1字节字段个数，之后每个字段为 2字节长度(大端) + 内容，字段依次为 算法名、密钥ID、包装后的数据密钥
+type envelope struct {
+	// algorithm 加密算法名称
+	algorithm string
+	// keyID 加密所用密钥的ID，可以为空
+	keyID string
+	// wrappedKey 信封加密时，被主密钥包装后的数据密钥，可以为空
+	wrappedKey []byte
+	// payload 算法相关的密文，例如 nonce || ciphertext || tag
+	payload []byte
+	// rawHeader 解析时读到的原始头部，解密时按原始字节认证
+	rawHeader []byte
+}
+
+// header 序列化信封头部，头部同时作为AEAD的附加数据参与认证
+func (e *envelope) header() []byte {
+	fields := [][]byte{[]byte(e.algorithm), []byte(e.keyID)}
+	if len(e.wrappedKey) > 0 {
+		fields = append(fields, e.wrappedKey)
+	}
+	header := []byte{byte(len(fields))}
+	for _, field := range fields {
+		header = append(header, byte(len(field)>>8), byte(len(field)))
+		header = append(header, field...)
+	}
+	return header
+}
+
+// body 信封的二进制内容：header || payload
+func (e *envelope) body() []byte {
+	return append(e.header(), e.payload...)
+}
+
+// String 编码为url安全的字符串
+func (e *envelope) String() string {
+	return envelopePrefix + base64.RawURLEncoding.EncodeToString(e.body())
+}
+
+// isEnvelope 判断字符串是否为信封格式
+func isEnvelope(s string) bool {
+	return strings.HasPrefix(s, envelopePrefix)
+}
+
+// parseEnvelope 解析信封字符串
+func parseEnvelope(s string) (*envelope, error) {
+	if !isEnvelope(s) {
+		return nil, ErrInvalidEnvelope
+	}
+	body, err := base64.RawURLEncoding.DecodeString(s[len(envelopePrefix):])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidEncoding, err.Error())
+	}
+	return parseEnvelopeBody(body)
+}
+
+// parseEnvelopeBody 解析信封的二进制内容，见 envelope.body
+// 头部必须是 envelope.header 的规范编码：2个或3个字段，包装后的数据密钥不能为空
+// 否则同一个密文可以改写出多种头部，破坏AES-SIV相同明文得到相同密文的约定
+func parseEnvelopeBody(body []byte) (*envelope, error) {
+	if len(body) < 1 {
+		return nil, ErrInvalidEnvelope
+	}
+	count := int(body[0])
+	if count < 2 || count > 3 {
+		return nil, ErrInvalidEnvelope
+	}
+	offset := 1
+	fields := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		if len(body) < offset+2 {
+			return nil, ErrInvalidEnvelope
+		}
+		n := int(binary.BigEndian.Uint16(body[offset:]))
+		if len(body) < offset+2+n {
+			return nil, ErrInvalidEnvelope
+		}
+		fields = append(fields, body[offset+2:offset+2+n])
+		offset += 2 + n
+	}
+	if len(fields[0]) == 0 {
+		return nil, ErrInvalidEnvelope
+	}
+	e := &envelope{algorithm: string(fields[0]), keyID: string(fields[1]), payload: body[offset:],
+		rawHeader: body[:offset]}
+	if count > 2 {
+		e.wrappedKey = fields[2]
+	}
+	if !bytes.Equal(e.header(), e.rawHeader) {
+		return nil, ErrInvalidEnvelope
+	}
+	return e, nil
+}
+
+// additionalData 拼接信封头部与调用方的附加数据，解析得到的信封使用原始头部
+func (e *envelope) additionalData(additionalData []byte) []byte {
+	header := e.rawHeader
+	if header == nil {
+		header = e.header()
+	}
+	return append(append([]byte(nil), header...), additionalData...)
+}
@@ -0,0 +1,32 @@
+package encipherment
+
+import "errors"
+
+var (
+	// ErrInvalidEncoding 密文不是合法的base64编码
+	ErrInvalidEncoding = errors.New("invalid encoding")
+	// ErrInvalidKey key 长度不合法，各算法的要求见错误信息
+	ErrInvalidKey = errors.New("invalid key length")
+	// ErrInvalidBlockSize 密文长度不是分组长度的整数倍
+	ErrInvalidBlockSize = errors.New("invalid block size")
+	// ErrInvalidPadding 补码不合法，通常是key错误或密文被篡改
+	ErrInvalidPadding = errors.New("invalid padding")
+	// ErrInvalidEnvelope 密文不是合法的信封格式
+	ErrInvalidEnvelope = errors.New("invalid envelope")
+	// ErrWrappedKey 密文使用信封加密，需要通过 EnvelopeDecrypt 解密
+	ErrWrappedKey = errors.New("ciphertext has a wrapped data key, use EnvelopeDecrypt")
+	// ErrInvalidSignature 签名校验失败
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrInvalidToken token格式不合法
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrTokenExpired token已过期
+	ErrTokenExpired = errors.New("token is expired")
+	// ErrTokenNotValidYet token尚未生效
+	ErrTokenNotValidYet = errors.New("token is not valid yet")
+	// ErrPasswordMismatch 密码不正确
+	ErrPasswordMismatch = errors.New("password mismatch")
+	// ErrInvalidStream 流式密文被截断、重排或篡改
+	ErrInvalidStream = errors.New("invalid or tampered stream")
+	// ErrKeyNotFound 密钥环中不存在指定ID的密钥
+	ErrKeyNotFound = errors.New("key not found")
+)
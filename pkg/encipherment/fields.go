@@ -0,0 +1,326 @@
+package encipherment
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EncryptTag 字段加密标签，例如：
+//
+//	type Config struct {
+//		User     string
+//		Password string `json:"password" encrypt:"true"`
+//	}
+const EncryptTag = "encrypt"
+
+// FieldCipher 字段加解密使用的密码器，*Keyring 实现了该接口，单个密钥可以使用 NewFieldCipher
+type FieldCipher interface {
+	Seal(plaintext, additionalData []byte) (string, error)
+	Open(ciphertext string, additionalData []byte) ([]byte, error)
+}
+
+// NewFieldCipher 使用单个密钥的 FieldCipher，通过 Seal/Open 加解密
+// key length must 16, 24, or 32 bytes to select
+func NewFieldCipher(key []byte) FieldCipher {
+	return &keyFieldCipher{key: key}
+}
+
+type keyFieldCipher struct {
+	key []byte
+}
+
+func (k *keyFieldCipher) Seal(plaintext, additionalData []byte) (string, error) {
+	return Seal(plaintext, k.key, additionalData)
+}
+
+func (k *keyFieldCipher) Open(ciphertext string, additionalData []byte) ([]byte, error) {
+	return Open(ciphertext, k.key, additionalData)
+}
+
+// EncryptFields 加密v中所有带 encrypt:"true" 标签的字段，v必须是指针
+// 支持嵌套的结构体、指针、切片、数组、map与interface，带标签的字段类型可以是
+// string、[]byte、*string，或元素为string的切片、数组与map，空值不加密
+// 未导出类型的嵌入结构体同样会被处理，嵌入的未导出类型指针包含带标签的字段时返回错误
+func EncryptFields(v interface{}, cipher FieldCipher) error {
+	return walkFields(v, &fieldWalker{cipher: cipher, encrypt: true})
+}
+
+// DecryptFields 解密v中所有带 encrypt:"true" 标签的字段，v必须是指针
+func DecryptFields(v interface{}, cipher FieldCipher) error {
+	return walkFields(v, &fieldWalker{cipher: cipher})
+}
+
+// MarshalJSON 加密带标签的字段后序列化为json，不会修改v
+func MarshalJSON(v interface{}, cipher FieldCipher) ([]byte, error) {
+	encrypted, err := encryptedCopy(v, cipher)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encrypted)
+}
+
+// UnmarshalJSON 反序列化json并解密带标签的字段
+func UnmarshalJSON(data []byte, v interface{}, cipher FieldCipher) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	return DecryptFields(v, cipher)
+}
+
+// MarshalYAML 加密带标签的字段后序列化为yaml，不会修改v
+func MarshalYAML(v interface{}, cipher FieldCipher) ([]byte, error) {
+	encrypted, err := encryptedCopy(v, cipher)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(encrypted)
+}
+
+// UnmarshalYAML 反序列化yaml并解密带标签的字段
+func UnmarshalYAML(data []byte, v interface{}, cipher FieldCipher) error {
+	if err := yaml.Unmarshal(data, v); err != nil {
+		return err
+	}
+	return DecryptFields(v, cipher)
+}
+
+func walkFields(v interface{}, w *fieldWalker) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("%T is not a non-nil pointer", v)
+	}
+	out, err := w.walk(rv.Elem(), "")
+	if err != nil {
+		return err
+	}
+	rv.Elem().Set(out)
+	return nil
+}
+
+// encryptedCopy 返回加密后的副本
+func encryptedCopy(v interface{}, cipher FieldCipher) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	out, err := (&fieldWalker{cipher: cipher, encrypt: true}).walk(reflect.ValueOf(v), "")
+	if err != nil {
+		return nil, err
+	}
+	return out.Interface(), nil
+}
+
+// fieldWalker 遍历并复制值，复制过程中加密或解密带标签的字段
+// 只复制遍历路径上的容器，原值不会被修改
+type fieldWalker struct {
+	cipher  FieldCipher
+	encrypt bool
+}
+
+func (w *fieldWalker) walk(v reflect.Value, path string) (reflect.Value, error) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v, nil
+		}
+		elem, err := w.walk(v.Elem(), path)
+		if err != nil {
+			return v, err
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(elem)
+		return out, nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, nil
+		}
+		elem, err := w.walk(v.Elem(), path)
+		if err != nil {
+			return v, err
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(elem)
+		return out, nil
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		if err := w.walkStruct(v, out, path); err != nil {
+			return v, err
+		}
+		return out, nil
+	case reflect.Slice, reflect.Array, reflect.Map:
+		switch v.Type().Elem().Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+			return w.each(v, path, w.walk)
+		}
+		//元素不可能包含带标签的字段，无需复制
+		return v, nil
+	default:
+		return v, nil
+	}
+}
+
+// walkStruct 处理结构体的字段，结果写入out，out的字段必须可以设置
+func (w *fieldWalker) walkStruct(v, out reflect.Value, path string) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.PkgPath != "" {
+			if !field.Anonymous {
+				//未导出字段不处理
+				continue
+			}
+			//未导出类型的嵌入结构体，其导出字段仍会被json序列化，需要原地处理
+			if field.Type.Kind() == reflect.Struct {
+				if err := w.walkStruct(v.Field(i), out.Field(i), path); err != nil {
+					return err
+				}
+				continue
+			}
+			//嵌入的指针无法复制，包含带标签的字段时返回错误，避免明文被序列化
+			if hasEncryptTag(field.Type, map[reflect.Type]bool{}) {
+				return fmt.Errorf("field %s: embedded %s of unexported type with encrypted fields is not supported",
+					joinPath(path, field.Name), field.Type)
+			}
+			continue
+		}
+		fieldPath := joinPath(path, field.Name)
+		var value reflect.Value
+		var err error
+		if encrypt, _ := strconv.ParseBool(field.Tag.Get(EncryptTag)); encrypt {
+			value, err = w.crypt(v.Field(i), fieldPath)
+		} else {
+			value, err = w.walk(v.Field(i), fieldPath)
+		}
+		if err != nil {
+			return err
+		}
+		out.Field(i).Set(value)
+	}
+	return nil
+}
+
+// hasEncryptTag 判断类型中是否包含带标签的字段
+func hasEncryptTag(t reflect.Type, visited map[reflect.Type]bool) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || visited[t] {
+		return false
+	}
+	visited[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if encrypt, _ := strconv.ParseBool(field.Tag.Get(EncryptTag)); encrypt || hasEncryptTag(field.Type, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// crypt 加密或解密带标签的字段
+func (w *fieldWalker) crypt(v reflect.Value, path string) (reflect.Value, error) {
+	switch {
+	case v.Kind() == reflect.String:
+		if v.Len() == 0 {
+			return v, nil
+		}
+		result, err := w.cryptBytes([]byte(v.String()), path)
+		if err != nil {
+			return v, err
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.SetString(string(result))
+		return out, nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		if v.Len() == 0 {
+			return v, nil
+		}
+		result, err := w.cryptBytes(v.Bytes(), path)
+		if err != nil {
+			return v, err
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.SetBytes(result)
+		return out, nil
+	case v.Kind() == reflect.Ptr:
+		if v.IsNil() {
+			return v, nil
+		}
+		elem, err := w.crypt(v.Elem(), path)
+		if err != nil {
+			return v, err
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(elem)
+		return out, nil
+	case v.Kind() == reflect.Slice || v.Kind() == reflect.Array || v.Kind() == reflect.Map:
+		return w.each(v, path, w.crypt)
+	default:
+		return v, fmt.Errorf("field %s: unsupported type %s for %s tag", path, v.Type(), EncryptTag)
+	}
+}
+
+func (w *fieldWalker) cryptBytes(data []byte, path string) ([]byte, error) {
+	if w.encrypt {
+		sealed, err := w.cipher.Seal(data, nil)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt field %s: %w", path, err)
+		}
+		return []byte(sealed), nil
+	}
+	opened, err := w.cipher.Open(string(data), nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt field %s: %w", path, err)
+	}
+	return opened, nil
+}
+
+// each 对切片、数组、map的每个元素执行fn
+func (w *fieldWalker) each(v reflect.Value, path string,
+	fn func(reflect.Value, string) (reflect.Value, error)) (reflect.Value, error) {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return v, nil
+		}
+		var out reflect.Value
+		if v.Kind() == reflect.Slice {
+			out = reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		} else {
+			out = reflect.New(v.Type()).Elem()
+		}
+		for i := 0; i < v.Len(); i++ {
+			elem, err := fn(v.Index(i), fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return v, err
+			}
+			out.Index(i).Set(elem)
+		}
+		return out, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return v, nil
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			elem, err := fn(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key()))
+			if err != nil {
+				return v, err
+			}
+			out.SetMapIndex(iter.Key(), elem)
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,121 @@
+package encipherment
+
+import (
+	"strings"
+	"testing"
+)
+
+type credential struct {
+	Ak string `json:"ak" yaml:"ak"`
+	Sk string `json:"sk" yaml:"sk" encrypt:"true"`
+}
+
+type serviceConfig struct {
+	Name        string                `json:"name" yaml:"name"`
+	Password    string                `json:"password" yaml:"password" encrypt:"true"`
+	Token       *string               `json:"token" yaml:"token" encrypt:"true"`
+	Secret      []byte                `json:"secret" yaml:"secret" encrypt:"true"`
+	Tags        []string              `json:"tags" yaml:"tags" encrypt:"true"`
+	Credentials []credential          `json:"credentials" yaml:"credentials"`
+	Clouds      map[string]credential `json:"clouds" yaml:"clouds"`
+	Empty       string                `json:"empty" yaml:"empty" encrypt:"true"`
+}
+
+func newServiceConfig() *serviceConfig {
+	token := "token-value"
+	return &serviceConfig{
+		Name:        "idp",
+		Password:    "p@ssw0rd",
+		Token:       &token,
+		Secret:      []byte("secret"),
+		Tags:        []string{"a", "b"},
+		Credentials: []credential{{Ak: "ak1", Sk: "sk1"}},
+		Clouds:      map[string]credential{"aliyun": {Ak: "ak2", Sk: "sk2"}},
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	cipher := NewFieldCipher([]byte("launcherSoNBPlus"))
+	config := newServiceConfig()
+
+	data, err := MarshalJSON(config, cipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, plaintext := range []string{"p@ssw0rd", "token-value", "sk1", "sk2", `"a"`} {
+		if strings.Contains(string(data), plaintext) {
+			t.Fatal(plaintext, string(data))
+		}
+	}
+	for _, plaintext := range []string{"idp", "ak1", "ak2"} {
+		if !strings.Contains(string(data), plaintext) {
+			t.Fatal(plaintext, string(data))
+		}
+	}
+	//原值不被修改
+	if config.Password != "p@ssw0rd" || *config.Token != "token-value" || config.Clouds["aliyun"].Sk != "sk2" {
+		t.Fatal(config)
+	}
+
+	decoded := &serviceConfig{}
+	if err = UnmarshalJSON(data, decoded, cipher); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Password != "p@ssw0rd" || *decoded.Token != "token-value" || string(decoded.Secret) != "secret" ||
+		decoded.Tags[1] != "b" || decoded.Credentials[0].Sk != "sk1" || decoded.Clouds["aliyun"].Sk != "sk2" ||
+		decoded.Empty != "" {
+		t.Fatal(decoded)
+	}
+}
+
+func TestMarshalYAML(t *testing.T) {
+	keyring := NewKeyring()
+	_ = keyring.Add("k1", []byte("launcherSoNBPlus"))
+
+	data, err := MarshalYAML(newServiceConfig(), keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "p@ssw0rd") {
+		t.Fatal(string(data))
+	}
+	decoded := &serviceConfig{}
+	if err = UnmarshalYAML(data, decoded, keyring); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Password != "p@ssw0rd" || decoded.Clouds["aliyun"].Sk != "sk2" {
+		t.Fatal(decoded)
+	}
+}
+
+func TestEmbeddedFields(t *testing.T) {
+	cipher := NewFieldCipher([]byte("launcherSoNBPlus"))
+	//未导出类型的嵌入结构体，导出字段仍会被序列化
+	type embeddedConfig struct {
+		credential
+		Name string `json:"name"`
+	}
+	config := &embeddedConfig{credential: credential{Ak: "ak1", Sk: "sk1"}, Name: "idp"}
+	data, err := MarshalJSON(config, cipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "sk1") || !strings.Contains(string(data), "ak1") {
+		t.Fatal(string(data))
+	}
+	if config.Sk != "sk1" {
+		t.Fatal(config.Sk)
+	}
+	decoded := &embeddedConfig{}
+	if err = UnmarshalJSON(data, decoded, cipher); err != nil || decoded.Sk != "sk1" || decoded.Ak != "ak1" {
+		t.Fatal(decoded, err)
+	}
+
+	//嵌入的指针无法复制，不能静默输出明文
+	type embeddedPointer struct {
+		*credential
+	}
+	if data, err = MarshalJSON(&embeddedPointer{&credential{Sk: "sk1"}}, cipher); err == nil {
+		t.Fatal(string(data))
+	}
+}
@@ -0,0 +1,83 @@
+package encipherment
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+)
+
+// AlgorithmAesGcm AES-GCM 算法名称，写入信封头部
+const AlgorithmAesGcm = "aes-gcm"
+
+// Seal 使用AES-GCM加密，每次加密使用随机nonce，相同明文得到不同密文
+// key length must 16, 24, or 32 bytes to select
+// additionalData 为可选的附加数据，解密时必须提供相同的值，可以为nil
+// 返回url安全的带版本信封，可直接放在url参数中
+func Seal(plaintext, key, additionalData []byte) (string, error) {
+	return sealEnvelope(&envelope{algorithm: AlgorithmAesGcm}, key, plaintext, additionalData)
+}
+
+// Open 解密 Seal 生成的信封，密文被篡改或附加数据不一致时返回错误
+// 与 Decrypt 相同，会按信封头部的算法名称选择 Cipher
+func Open(ciphertext string, key, additionalData []byte) ([]byte, error) {
+	return Decrypt(ciphertext, key, additionalData)
+}
+
+// aesGcmCipher AES-GCM 的 Cipher 实现
+type aesGcmCipher struct{}
+
+func (aesGcmCipher) Name() string {
+	return AlgorithmAesGcm
+}
+
+func (aesGcmCipher) Encrypt(key, plaintext, additionalData []byte) ([]byte, error) {
+	return gcmSeal(key, plaintext, additionalData)
+}
+
+func (aesGcmCipher) Decrypt(key, payload, additionalData []byte) ([]byte, error) {
+	return gcmOpen(key, payload, additionalData)
+}
+
+// gcmSeal 返回 nonce || ciphertext || tag
+func gcmSeal(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newGcm(key)
+	if err != nil {
+		return nil, err
+	}
+	return aeadSeal(aead, plaintext, additionalData)
+}
+
+// aeadSeal 使用随机nonce加密，返回 nonce || ciphertext || tag
+func aeadSeal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// gcmOpen 解密 gcmSeal 的输出
+func gcmOpen(key, payload, additionalData []byte) ([]byte, error) {
+	aead, err := newGcm(key)
+	if err != nil {
+		return nil, err
+	}
+	return aeadOpen(aead, payload, additionalData)
+}
+
+// aeadOpen 解密 aeadSeal 的输出
+func aeadOpen(aead cipher.AEAD, payload, additionalData []byte) ([]byte, error) {
+	if len(payload) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrInvalidEnvelope
+	}
+	nonce, sealed := payload[:aead.NonceSize()], payload[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, additionalData)
+}
+
+func newGcm(key []byte) (cipher.AEAD, error) {
+	block, err := newAesBlock(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
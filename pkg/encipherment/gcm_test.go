@@ -0,0 +1,91 @@
+package encipherment
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	key := []byte("launcherSoNBPlus")
+	orig := []byte("EV8mXRBtnPLVxtIhoxEA3vbTGDqUeBDlUUvu4Kds")
+	ad := []byte("user-1")
+
+	first, err := Seal(orig, key, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Seal(orig, key, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//随机nonce，相同明文的密文不同
+	if first == second {
+		t.Fatal("same ciphertext for same plaintext")
+	}
+	if !strings.HasPrefix(first, envelopePrefix) {
+		t.Fatal(first)
+	}
+
+	plaintext, err := Open(first, key, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plaintext, orig) {
+		t.Fatal(string(plaintext))
+	}
+
+	//附加数据不一致
+	if _, err = Open(first, key, []byte("user-2")); err == nil {
+		t.Fatal("expected error for wrong additional data")
+	}
+
+	//篡改密文
+	tampered := []byte(first)
+	middle := len(tampered) / 2
+	if tampered[middle] == 'A' {
+		tampered[middle] = 'B'
+	} else {
+		tampered[middle] = 'A'
+	}
+	if _, err = Open(string(tampered), key, ad); err == nil {
+		t.Fatal("expected error for tampered ciphertext")
+	}
+
+	//旧格式密文
+	legacy, _ := AesEncrypt(string(orig), string(key))
+	if _, err = Open(legacy, key, ad); err != ErrInvalidEnvelope {
+		t.Fatal(err)
+	}
+}
+
+func TestEnvelopeHeader(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	gcm, _ := Seal([]byte("hello"), key, nil)
+	siv, _ := SealDeterministic([]byte("hello"), key, nil)
+	for _, sealed := range []string{gcm, siv} {
+		e, err := parseEnvelope(sealed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		header := e.header()
+		//改写头部的字段个数，追加空字段或删除字段后的密文都不能解密
+		rewrite := func(count byte, extra ...byte) string {
+			body := append([]byte{count}, header[1:]...)
+			body = append(append(body, extra...), e.payload...)
+			return envelopePrefix + base64.RawURLEncoding.EncodeToString(body)
+		}
+		for _, rewritten := range []string{rewrite(3, 0, 0), rewrite(4, 0, 0, 0, 0), rewrite(1)} {
+			if _, err = parseEnvelope(rewritten); err != ErrInvalidEnvelope {
+				t.Fatal(err)
+			}
+			if _, err = Open(rewritten, key, nil); err == nil {
+				t.Fatal("expected error for rewritten header")
+			}
+			if _, err = OpenDeterministic(rewritten, key, nil); err == nil {
+				t.Fatal("expected error for rewritten header")
+			}
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package encipherment
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// KDFPbkdf2 PBKDF2-HMAC-SHA256
+	KDFPbkdf2 = "pbkdf2-sha256"
+	// KDFScrypt scrypt
+	KDFScrypt = "scrypt"
+
+	defaultSaltLen          = 16
+	defaultKeyLen           = 32
+	defaultPbkdf2Iterations = 600000
+	defaultScryptN          = 1 << 15
+	defaultScryptR          = 8
+	defaultScryptP          = 1
+
+	// 参数可能来自保存的字符串，限制上限避免被构造的参数耗尽CPU与内存
+	maxPbkdf2Iterations = 10000000
+	maxScryptN          = 1 << 20
+	maxScryptR          = 32
+	maxScryptP          = 16
+	// maxScryptMemory scrypt 需要 128*N*r 字节内存
+	maxScryptMemory = 1 << 30
+)
+
+// KDFParams 密钥派生参数
+// 参数通过 String 编码后可以与密文一起保存，之后用相同的口令再次派生出相同的密钥
+// 编码格式：$pbkdf2-sha256$i=600000,l=32$<salt> 或 $scrypt$ln=15,r=8,p=1,l=32$<salt>
+type KDFParams struct {
+	// Algorithm 派生算法，KDFPbkdf2 或 KDFScrypt
+	Algorithm string
+	// Salt 盐值
+	Salt []byte
+	// Iterations pbkdf2 迭代次数，不超过 10000000
+	Iterations int
+	// N scrypt CPU/内存开销参数，必须是大于1的2的幂，不超过 2^20
+	N int
+	// R scrypt 块大小参数，不超过32，且 128*N*R 不超过1GB
+	R int
+	// P scrypt 并行参数，不超过16
+	P int
+	// KeyLen 派生密钥的长度，AES要求 16/24/32
+	KeyLen int
+}
+
+// DerivedKey 派生出的密钥及其参数
+type DerivedKey struct {
+	// Key 密钥，可直接用于 Seal/Open、Keyring 等以 []byte 为key的函数
+	Key []byte
+	// Params 派生参数
+	Params *KDFParams
+}
+
+// AesKey 返回字符串形式的key，用于 AesEncrypt/AesDecrypt 等以 string 为key的函数
+func (k *DerivedKey) AesKey() string {
+	return string(k.Key)
+}
+
+// NewPbkdf2Params 使用随机盐值与默认迭代次数构建pbkdf2参数
+func NewPbkdf2Params() (*KDFParams, error) {
+	salt, err := randomBytes(defaultSaltLen)
+	if err != nil {
+		return nil, err
+	}
+	return &KDFParams{Algorithm: KDFPbkdf2, Salt: salt, Iterations: defaultPbkdf2Iterations, KeyLen: defaultKeyLen}, nil
+}
+
+// NewScryptParams 使用随机盐值与默认开销参数构建scrypt参数
+func NewScryptParams() (*KDFParams, error) {
+	salt, err := randomBytes(defaultSaltLen)
+	if err != nil {
+		return nil, err
+	}
+	return &KDFParams{Algorithm: KDFScrypt, Salt: salt, N: defaultScryptN, R: defaultScryptR, P: defaultScryptP,
+		KeyLen: defaultKeyLen}, nil
+}
+
+// DeriveKey 根据口令与参数派生密钥
+func DeriveKey(passphrase string, params *KDFParams) (*DerivedKey, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	var key []byte
+	switch params.Algorithm {
+	case KDFPbkdf2:
+		key = pbkdf2.Key([]byte(passphrase), params.Salt, params.Iterations, params.KeyLen, sha256.New)
+	case KDFScrypt:
+		var err error
+		key, err = scrypt.Key([]byte(passphrase), params.Salt, params.N, params.R, params.P, params.KeyLen)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &DerivedKey{Key: key, Params: params}, nil
+}
+
+// DeriveKeyFromString 使用 KDFParams.String 编码的参数派生密钥
+func DeriveKeyFromString(passphrase, params string) (*DerivedKey, error) {
+	p, err := ParseKDFParams(params)
+	if err != nil {
+		return nil, err
+	}
+	return DeriveKey(passphrase, p)
+}
+
+// String 编码派生参数，不包含密钥
+func (p *KDFParams) String() string {
+	var options string
+	switch p.Algorithm {
+	case KDFPbkdf2:
+		options = fmt.Sprintf("i=%d,l=%d", p.Iterations, p.KeyLen)
+	case KDFScrypt:
+		options = fmt.Sprintf("ln=%d,r=%d,p=%d,l=%d", bits.TrailingZeros(uint(p.N)), p.R, p.P, p.KeyLen)
+	}
+	return fmt.Sprintf("$%s$%s$%s", p.Algorithm, options, base64.RawStdEncoding.EncodeToString(p.Salt))
+}
+
+// ParseKDFParams 解析 KDFParams.String 编码的参数
+func ParseKDFParams(s string) (*KDFParams, error) {
+	parts := strings.Split(s, "$")
+	if len(parts) != 4 || parts[0] != "" {
+		return nil, fmt.Errorf("invalid kdf params: %s", s)
+	}
+	params := &KDFParams{Algorithm: parts[1]}
+	options, err := parseOptions(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	switch params.Algorithm {
+	case KDFPbkdf2:
+		params.Iterations = options["i"]
+	case KDFScrypt:
+		if ln := options["ln"]; ln > 0 && ln < 63 {
+			params.N = 1 << ln
+		}
+		params.R = options["r"]
+		params.P = options["p"]
+	}
+	params.KeyLen = options["l"]
+	params.Salt, err = base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidEncoding, err.Error())
+	}
+	return params, params.validate()
+}
+
+func (p *KDFParams) validate() error {
+	if p == nil {
+		return fmt.Errorf("kdf params is nil")
+	}
+	if len(p.Salt) == 0 {
+		return fmt.Errorf("kdf salt is empty")
+	}
+	if p.KeyLen != 16 && p.KeyLen != 24 && p.KeyLen != 32 {
+		return fmt.Errorf("%w: kdf key length %d", ErrInvalidKey, p.KeyLen)
+	}
+	switch p.Algorithm {
+	case KDFPbkdf2:
+		if p.Iterations <= 0 || p.Iterations > maxPbkdf2Iterations {
+			return fmt.Errorf("invalid pbkdf2 iterations: %d", p.Iterations)
+		}
+	case KDFScrypt:
+		if p.N <= 1 || p.N > maxScryptN || p.N&(p.N-1) != 0 || p.R <= 0 || p.R > maxScryptR ||
+			p.P <= 0 || p.P > maxScryptP || 128*p.N*p.R > maxScryptMemory {
+			return fmt.Errorf("invalid scrypt params: N=%d, r=%d, p=%d", p.N, p.R, p.P)
+		}
+	default:
+		return fmt.Errorf("unsupported kdf: %s", p.Algorithm)
+	}
+	return nil
+}
+
+// parseOptions 解析 k1=v1,k2=v2 格式的数字参数
+func parseOptions(s string) (map[string]int, error) {
+	options := make(map[string]int)
+	for _, option := range strings.Split(s, ",") {
+		kv := strings.SplitN(option, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid option: %s", option)
+		}
+		v, err := strconv.Atoi(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid option: %s", option)
+		}
+		options[kv[0]] = v
+	}
+	return options, nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
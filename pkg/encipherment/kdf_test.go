@@ -0,0 +1,68 @@
+package encipherment
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDeriveKey(t *testing.T) {
+	pbkdf2Params, err := NewPbkdf2Params()
+	if err != nil {
+		t.Fatal(err)
+	}
+	//测试中降低开销
+	pbkdf2Params.Iterations = 1000
+	scryptParams, err := NewScryptParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	scryptParams.N = 1 << 10
+
+	for _, params := range []*KDFParams{pbkdf2Params, scryptParams} {
+		derived, err := DeriveKey("my passphrase", params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(derived.Key) != 32 {
+			t.Fatal(len(derived.Key))
+		}
+
+		//使用编码后的参数可以派生出相同的密钥
+		again, err := DeriveKeyFromString("my passphrase", params.String())
+		if err != nil {
+			t.Fatal(params.String(), err)
+		}
+		if !bytes.Equal(derived.Key, again.Key) {
+			t.Fatal(params.String())
+		}
+		other, _ := DeriveKeyFromString("other passphrase", params.String())
+		if bytes.Equal(derived.Key, other.Key) {
+			t.Fatal(params.String())
+		}
+
+		encryptCode, err := AesEncrypt("hello", derived.AesKey())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if AesDecrypt(encryptCode, again.AesKey()) != "hello" {
+			t.Fatal(encryptCode)
+		}
+		sealed, err := Seal([]byte("hello"), derived.Key, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plaintext, err := Open(sealed, again.Key, nil); err != nil || string(plaintext) != "hello" {
+			t.Fatal(err)
+		}
+	}
+
+	for _, bad := range []string{"", "$md5$i=1$c2FsdA", "$scrypt$ln=0,r=8,p=1,l=32$c2FsdA", "$pbkdf2-sha256$i=1,l=32$",
+		//超过上限的参数
+		"$pbkdf2-sha256$i=2000000000,l=32$c2FsdA", "$scrypt$ln=30,r=8,p=1,l=32$c2FsdA",
+		"$scrypt$ln=20,r=32,p=1,l=32$c2FsdA", "$scrypt$ln=15,r=8,p=1000,l=32$c2FsdA",
+		"$pbkdf2-sha256$i=1000,l=2000000000$c2FsdA", "$pbkdf2-sha256$i=1000,l=20$c2FsdA"} {
+		if _, err := ParseKDFParams(bad); err == nil {
+			t.Fatal(bad)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package encipherment
+
+import (
+	"context"
+	"errors"
+)
+
+// dataKeySize 信封加密中数据密钥的长度，AES-256
+const dataKeySize = 32
+
+// KeyProvider 主密钥提供者，用于包装与解包数据密钥
+// 主密钥不离开提供者，应用配置中只需要保存访问提供者的凭证
+// 本地实现见 NewLocalKeyProvider，云厂商KMS可以通过实现该接口接入
+type KeyProvider interface {
+	// WrapKey 使用当前主密钥包装数据密钥，返回包装后的密钥与主密钥ID
+	WrapKey(ctx context.Context, dataKey []byte) (wrapped []byte, keyID string, err error)
+	// UnwrapKey 使用keyID对应的主密钥解包数据密钥
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// NewLocalKeyProvider 基于本地密钥环的 KeyProvider，使用主密钥进行AES-GCM包装
+func NewLocalKeyProvider(keyring *Keyring) KeyProvider {
+	return &localKeyProvider{keyring: keyring}
+}
+
+// NewLocalKeyProviderFromFile 从密钥环文件构建本地 KeyProvider，文件格式见 ParseKeyring
+func NewLocalKeyProviderFromFile(path string) (KeyProvider, error) {
+	keyring, err := LoadKeyring(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewLocalKeyProvider(keyring), nil
+}
+
+// localKeyProvider KeyProvider 本地实现
+type localKeyProvider struct {
+	keyring *Keyring
+}
+
+func (l *localKeyProvider) WrapKey(_ context.Context, dataKey []byte) ([]byte, string, error) {
+	id, key, err := l.keyring.key("")
+	if err != nil {
+		return nil, "", err
+	}
+	//密钥ID作为附加数据，防止包装后的密钥被挪用到其他主密钥下
+	wrapped, err := gcmSeal(key, dataKey, []byte(id))
+	if err != nil {
+		return nil, "", err
+	}
+	return wrapped, id, nil
+}
+
+func (l *localKeyProvider) UnwrapKey(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	if keyID == "" {
+		return nil, ErrKeyNotFound
+	}
+	_, key, err := l.keyring.key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return gcmOpen(key, wrapped, []byte(keyID))
+}
+
+// EnvelopeEncrypt 信封加密
+// 每次加密生成随机的数据密钥，使用数据密钥对明文进行AES-GCM加密，
+// 数据密钥由 KeyProvider 包装后与主密钥ID一起写入密文头部
+func EnvelopeEncrypt(ctx context.Context, provider KeyProvider, plaintext, additionalData []byte) (string, error) {
+	dataKey, err := randomBytes(dataKeySize)
+	if err != nil {
+		return "", err
+	}
+	wrapped, keyID, err := provider.WrapKey(ctx, dataKey)
+	if err != nil {
+		return "", err
+	}
+	if len(wrapped) == 0 || len(wrapped) > 0xffff || len(keyID) > 0xffff {
+		return "", errors.New("invalid wrapped key")
+	}
+	return sealEnvelope(&envelope{algorithm: AlgorithmAesGcm, keyID: keyID, wrappedKey: wrapped},
+		dataKey, plaintext, additionalData)
+}
+
+// EnvelopeDecrypt 解密 EnvelopeEncrypt 生成的密文
+func EnvelopeDecrypt(ctx context.Context, provider KeyProvider, ciphertext string, additionalData []byte) ([]byte, error) {
+	e, err := parseEnvelope(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.wrappedKey) == 0 {
+		return nil, errors.New("ciphertext has no wrapped data key")
+	}
+	dataKey, err := provider.UnwrapKey(ctx, e.keyID, e.wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	return openEnvelope(e, dataKey, additionalData)
+}
@@ -0,0 +1,51 @@
+package encipherment
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnvelopeEncrypt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keyring")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "keyring.yaml")
+	content := "primary: master-1\nkeys:\n  master-1: " +
+		base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")) + "\n"
+	if err = ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	provider, err := NewLocalKeyProviderFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	orig := []byte("EV8mXRBtnPLVxtIhoxEA3vbTGDqUeBDlUUvu4Kds")
+	ciphertext, err := EnvelopeEncrypt(ctx, provider, orig, []byte("ad"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, _ := parseEnvelope(ciphertext)
+	if e.keyID != "master-1" || len(e.wrappedKey) == 0 {
+		t.Fatal(e.keyID)
+	}
+
+	plaintext, err := EnvelopeDecrypt(ctx, provider, ciphertext, []byte("ad"))
+	if err != nil || string(plaintext) != string(orig) {
+		t.Fatal(string(plaintext), err)
+	}
+	if _, err = EnvelopeDecrypt(ctx, provider, ciphertext, nil); err == nil {
+		t.Fatal("expected error for wrong additional data")
+	}
+	if _, err = Open(ciphertext, []byte("0123456789abcdef0123456789abcdef"), []byte("ad")); !errors.Is(err, ErrWrappedKey) {
+		t.Fatal(err)
+	}
+}
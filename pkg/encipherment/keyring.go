@@ -0,0 +1,322 @@
+package encipherment
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Keyring 密钥环，保存多个带ID的密钥，其中一个为主密钥
+// 加密总是使用主密钥，并将密钥ID写入密文，解密时按密文中的密钥ID选择密钥
+// 因此可以在不影响已签发密文的情况下轮换密钥
+type Keyring struct {
+	lock      sync.RWMutex
+	keys      map[string][]byte
+	primary   string
+	algorithm string
+}
+
+// NewKeyring 构建空的密钥环，默认使用AES-GCM加密
+func NewKeyring() *Keyring {
+	return &Keyring{keys: make(map[string][]byte), algorithm: AlgorithmAesGcm}
+}
+
+// SetAlgorithm 指定加密使用的算法，算法必须已通过 RegisterCipher 注册，主密钥的长度必须满足算法的要求
+// 解密总是按密文头部的算法名称选择算法，因此切换算法不影响已有密文
+func (k *Keyring) SetAlgorithm(algorithm string) error {
+	if _, err := envelopeCipher(algorithm); err != nil {
+		return err
+	}
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if k.primary != "" {
+		if err := checkAlgorithmKey(algorithm, k.keys[k.primary]); err != nil {
+			return err
+		}
+	}
+	k.algorithm = algorithm
+	return nil
+}
+
+// Algorithm 返回加密使用的算法名称
+func (k *Keyring) Algorithm() string {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+	return k.algorithm
+}
+
+// Add 添加密钥，key length must 16, 24, or 32 bytes to select
+// 第一个添加的密钥会成为主密钥，其长度必须满足当前算法的要求
+func (k *Keyring) Add(id string, key []byte) error {
+	if err := checkKeyringKey(id, key); err != nil {
+		return err
+	}
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	return k.add(id, key)
+}
+
+// SetPrimary 指定主密钥，密钥长度必须满足当前算法的要求
+func (k *Keyring) SetPrimary(id string) error {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	key, ok := k.keys[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+	}
+	if err := checkAlgorithmKey(k.algorithm, key); err != nil {
+		return err
+	}
+	k.primary = id
+	return nil
+}
+
+// Primary 返回主密钥ID，密钥环为空时返回空字符串
+func (k *Keyring) Primary() string {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+	return k.primary
+}
+
+// IDs 返回所有密钥ID，按字典序排列
+func (k *Keyring) IDs() []string {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Remove 删除密钥，主密钥不能删除
+// 删除后，使用该密钥加密的密文将无法解密
+func (k *Keyring) Remove(id string) error {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if _, ok := k.keys[id]; !ok {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+	}
+	if id == k.primary {
+		return fmt.Errorf("can not remove primary key %s", id)
+	}
+	delete(k.keys, id)
+	return nil
+}
+
+// Rotate 添加新密钥并设置为主密钥，旧密钥保留用于解密
+// 添加与设置主密钥在同一次加锁中完成，并发加密不会使用到其他密钥，新密钥的长度必须满足当前算法的要求
+func (k *Keyring) Rotate(id string, key []byte) error {
+	if err := checkKeyringKey(id, key); err != nil {
+		return err
+	}
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if err := checkAlgorithmKey(k.algorithm, key); err != nil {
+		return err
+	}
+	if err := k.add(id, key); err != nil {
+		return err
+	}
+	k.primary = id
+	return nil
+}
+
+// checkKeyringKey 校验密钥ID与密钥长度
+func checkKeyringKey(id string, key []byte) error {
+	if len(id) == 0 || len(id) > 0xffff {
+		return fmt.Errorf("invalid key id: %q", id)
+	}
+	_, err := newAesBlock(key)
+	return err
+}
+
+// checkAlgorithmKey 校验密钥可以用于指定算法，各算法对密钥长度的要求不同，因此试加密一次空明文
+func checkAlgorithmKey(algorithm string, key []byte) error {
+	c, err := envelopeCipher(algorithm)
+	if err != nil {
+		return err
+	}
+	_, err = c.Encrypt(key, nil, nil)
+	return err
+}
+
+// add 添加密钥，调用方需要持有写锁
+func (k *Keyring) add(id string, key []byte) error {
+	if _, ok := k.keys[id]; ok {
+		return fmt.Errorf("key %s already exists", id)
+	}
+	if k.primary == "" {
+		if err := checkAlgorithmKey(k.algorithm, key); err != nil {
+			return err
+		}
+	}
+	k.keys[id] = append([]byte(nil), key...)
+	if k.primary == "" {
+		k.primary = id
+	}
+	return nil
+}
+
+// key 获取指定ID的密钥，id为空时返回主密钥
+func (k *Keyring) key(id string) (string, []byte, error) {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+	if id == "" {
+		id = k.primary
+	}
+	key, ok := k.keys[id]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+	}
+	return id, key, nil
+}
+
+// Seal 使用主密钥加密，密文中带有主密钥ID与算法名称
+func (k *Keyring) Seal(plaintext, additionalData []byte) (string, error) {
+	id, key, err := k.key("")
+	if err != nil {
+		return "", err
+	}
+	return sealEnvelope(&envelope{algorithm: k.Algorithm(), keyID: id}, key, plaintext, additionalData)
+}
+
+// Open 按密文中的密钥ID选择密钥、按算法名称选择算法解密，密文中没有密钥ID时使用主密钥
+func (k *Keyring) Open(ciphertext string, additionalData []byte) ([]byte, error) {
+	e, err := parseEnvelope(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.wrappedKey) > 0 {
+		return nil, ErrWrappedKey
+	}
+	_, key, err := k.key(e.keyID)
+	if err != nil {
+		return nil, err
+	}
+	return openEnvelope(e, key, additionalData)
+}
+
+// ReEncrypt 将密文升级为使用当前主密钥与算法加密
+// SealDeterministic 生成的AES-SIV密文仍使用AES-SIV加密，迁移后相同明文的密文仍然相同，可以继续等值查询
+// 密文已经使用主密钥与目标算法加密时原样返回，changed 为false
+func (k *Keyring) ReEncrypt(ciphertext string, additionalData []byte) (result string, changed bool, err error) {
+	e, err := parseEnvelope(ciphertext)
+	if err != nil {
+		return "", false, err
+	}
+	algorithm := k.Algorithm()
+	if e.algorithm == AlgorithmAesSiv {
+		algorithm = AlgorithmAesSiv
+	}
+	if e.keyID != "" && e.keyID == k.Primary() && e.algorithm == algorithm {
+		return ciphertext, false, nil
+	}
+	plaintext, err := k.Open(ciphertext, additionalData)
+	if err != nil {
+		return "", false, err
+	}
+	id, key, err := k.key("")
+	if err != nil {
+		return "", false, err
+	}
+	result, err = sealEnvelope(&envelope{algorithm: algorithm, keyID: id}, key, plaintext, additionalData)
+	if err != nil {
+		return "", false, err
+	}
+	return result, true, nil
+}
+
+// keyringFile 密钥环文件格式，支持yaml与json
+//
+//	primary: "2023"
+//	algorithm: aes-gcm
+//	keys:
+//	  "2022": <base64编码的密钥>
+//	  "2023": <base64编码的密钥>
+type keyringFile struct {
+	Primary   string            `json:"primary" yaml:"primary"`
+	Algorithm string            `json:"algorithm,omitempty" yaml:"algorithm,omitempty"`
+	Keys      map[string]string `json:"keys" yaml:"keys"`
+}
+
+// ParseKeyring 解析yaml或json格式的密钥环，密钥使用标准base64编码
+func ParseKeyring(data []byte) (*Keyring, error) {
+	file := keyringFile{}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	keyring := NewKeyring()
+	for id, encoded := range file.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %s: %s", ErrInvalidEncoding, id, err.Error())
+		}
+		if err = keyring.Add(id, key); err != nil {
+			return nil, err
+		}
+	}
+	if len(file.Keys) == 0 {
+		return nil, errors.New("keyring has no keys")
+	}
+	if file.Primary == "" && len(file.Keys) > 1 {
+		return nil, errors.New("keyring primary key is not specified")
+	}
+	if file.Primary != "" {
+		if err := keyring.SetPrimary(file.Primary); err != nil {
+			return nil, err
+		}
+	}
+	if file.Algorithm != "" {
+		if err := keyring.SetAlgorithm(file.Algorithm); err != nil {
+			return nil, err
+		}
+	}
+	return keyring, nil
+}
+
+// MarshalKeyring 将密钥环编码为yaml格式，格式见 ParseKeyring
+// 编码结果中包含密钥明文，保存时需要限制文件权限
+// Keyring 没有实现 yaml.Marshaler 与 json.Marshaler，序列化包含密钥环的结构体时不会输出密钥
+func MarshalKeyring(k *Keyring) ([]byte, error) {
+	return yaml.Marshal(k.file())
+}
+
+// MarshalKeyringJSON 将密钥环编码为缩进的json格式，见 MarshalKeyring
+func MarshalKeyringJSON(k *Keyring) ([]byte, error) {
+	data, err := json.MarshalIndent(k.file(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// file 将密钥环转换为文件格式，默认算法不写入文件
+func (k *Keyring) file() keyringFile {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+	file := keyringFile{Primary: k.primary, Keys: make(map[string]string, len(k.keys))}
+	if k.algorithm != AlgorithmAesGcm {
+		file.Algorithm = k.algorithm
+	}
+	for id, key := range k.keys {
+		file.Keys[id] = base64.StdEncoding.EncodeToString(key)
+	}
+	return file
+}
+
+// LoadKeyring 从文件加载密钥环，格式见 ParseKeyring
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeyring(data)
+}
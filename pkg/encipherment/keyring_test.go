@@ -0,0 +1,107 @@
+package encipherment
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestKeyringRotate(t *testing.T) {
+	keyring := NewKeyring()
+	if err := keyring.Add("2022", []byte("launcherSoNBPlus")); err != nil {
+		t.Fatal(err)
+	}
+	if err := keyring.Add("bad", []byte("short")); !errors.Is(err, ErrInvalidKey) {
+		t.Fatal(err)
+	}
+
+	orig := []byte("EV8mXRBtnPLVxtIhoxEA3vbTGDqUeBDlUUvu4Kds")
+	old, err := keyring.Seal(orig, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = keyring.Rotate("2023", []byte("0123456789abcdef0123456789abcdef")); err != nil {
+		t.Fatal(err)
+	}
+	if keyring.Primary() != "2023" {
+		t.Fatal(keyring.Primary())
+	}
+	if err = keyring.Rotate("2022", []byte("0123456789abcdef")); err == nil || keyring.Primary() != "2023" {
+		t.Fatal("rotate to an existing key id", keyring.Primary(), err)
+	}
+
+	//旧密文仍可解密
+	plaintext, err := keyring.Open(old, nil)
+	if err != nil || string(plaintext) != string(orig) {
+		t.Fatal(string(plaintext), err)
+	}
+
+	upgraded, changed, err := keyring.ReEncrypt(old, nil)
+	if err != nil || !changed {
+		t.Fatal(changed, err)
+	}
+	e, _ := parseEnvelope(upgraded)
+	if e.keyID != "2023" {
+		t.Fatal(e.keyID)
+	}
+	if _, changed, _ = keyring.ReEncrypt(upgraded, nil); changed {
+		t.Fatal("already encrypted with primary key")
+	}
+
+	//旧密钥删除后无法解密旧密文
+	if err = keyring.Remove("2022"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = keyring.Open(old, nil); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatal(err)
+	}
+	if err = keyring.Remove("2023"); err == nil {
+		t.Fatal("primary key removed")
+	}
+}
+
+func TestKeyringMarshal(t *testing.T) {
+	keyring := NewKeyring()
+	_ = keyring.Add("2022", []byte("launcherSoNBPlus"))
+	_ = keyring.Rotate("2023", []byte("0123456789abcdef0123456789abcdef"))
+	if err := keyring.SetAlgorithm(AlgorithmAesSiv); err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := keyring.Seal([]byte("hello"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, marshal := range []func(*Keyring) ([]byte, error){MarshalKeyring, MarshalKeyringJSON} {
+		data, err := marshal(keyring)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := ParseKeyring(data)
+		if err != nil {
+			t.Fatal(string(data), err)
+		}
+		if parsed.Primary() != "2023" || parsed.Algorithm() != AlgorithmAesSiv || len(parsed.IDs()) != 2 {
+			t.Fatalf("unexpected keyring: %s", data)
+		}
+		if plaintext, err := parsed.Open(sealed, nil); err != nil || string(plaintext) != "hello" {
+			t.Fatal(string(plaintext), err)
+		}
+	}
+
+	//序列化包含密钥环的结构体时不能输出密钥
+	holder := struct{ Keyring *Keyring }{keyring}
+	for _, marshal := range []func(interface{}) ([]byte, error){yaml.Marshal, json.Marshal} {
+		data, err := marshal(holder)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=") {
+			t.Fatal(string(data))
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package encipherment
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// Format 密文格式
+type Format string
+
+const (
+	// FormatLegacy AesEncrypt 生成的旧格式：RawURLEncoding 编码的 AES-CBC 密文
+	FormatLegacy Format = "legacy"
+	// FormatEnvelope 带版本的信封格式，见 Seal、Encrypt、Keyring.Seal
+	FormatEnvelope Format = "envelope"
+)
+
+// DetectFormat 判断密文格式，旧格式不会包含信封前缀中的 '.'
+func DetectFormat(ciphertext string) Format {
+	if isEnvelope(ciphertext) {
+		return FormatEnvelope
+	}
+	return FormatLegacy
+}
+
+// DecryptAny 解密旧格式或信封格式的密文，并返回识别出的格式
+// 旧格式使用key进行AES-CBC解密，additionalData 只用于信封格式
+func DecryptAny(ciphertext string, key, additionalData []byte) ([]byte, Format, error) {
+	format := DetectFormat(ciphertext)
+	if format == FormatEnvelope {
+		plaintext, err := Decrypt(ciphertext, key, additionalData)
+		return plaintext, format, err
+	}
+	plaintext, err := decryptLegacy(ciphertext, key)
+	return plaintext, format, err
+}
+
+func decryptLegacy(ciphertext string, key []byte) ([]byte, error) {
+	//使用RawURLEncoding 不要使用StdEncoding
+	decryptedByte, err := base64.RawURLEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidEncoding, err.Error())
+	}
+	return cbcDecrypt(key, decryptedByte)
+}
+
+// Migrator 将旧格式密文逐步迁移为密钥环加密的信封格式
+// 旧格式使用 LegacyKey 解密，信封格式使用 Keyring 解密
+type Migrator struct {
+	// LegacyKey 旧版 AesEncrypt 使用的key
+	LegacyKey []byte
+	// Keyring 新格式使用的密钥环，加密总是使用主密钥
+	Keyring *Keyring
+	// AdditionalData 信封格式的附加数据，可以为nil
+	AdditionalData []byte
+}
+
+// MigrateResult 批量迁移中单个密文的结果
+type MigrateResult struct {
+	// Ciphertext 新密文，失败时为原密文
+	Ciphertext string
+	// Format 原密文的格式
+	Format Format
+	// Changed 密文是否被重新加密，为true时需要写回存储
+	Changed bool
+	// Err 迁移失败的原因
+	Err error
+}
+
+// Decrypt 解密旧格式或信封格式的密文
+func (m *Migrator) Decrypt(ciphertext string) ([]byte, Format, error) {
+	format := DetectFormat(ciphertext)
+	if format == FormatEnvelope {
+		plaintext, err := m.keyring().Open(ciphertext, m.AdditionalData)
+		return plaintext, format, err
+	}
+	plaintext, err := decryptLegacy(ciphertext, m.LegacyKey)
+	return plaintext, format, err
+}
+
+// ReEncrypt 迁移单个密文
+// 旧格式解密后使用主密钥重新加密；信封格式未使用主密钥与当前算法时重新加密，否则原样返回
+// AES-SIV密文迁移后仍为AES-SIV密文，见 Keyring.ReEncrypt
+func (m *Migrator) ReEncrypt(ciphertext string) MigrateResult {
+	result := MigrateResult{Ciphertext: ciphertext, Format: DetectFormat(ciphertext)}
+	if result.Format == FormatEnvelope {
+		upgraded, changed, err := m.keyring().ReEncrypt(ciphertext, m.AdditionalData)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		result.Ciphertext, result.Changed = upgraded, changed
+		return result
+	}
+	plaintext, err := decryptLegacy(ciphertext, m.LegacyKey)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	upgraded, err := m.keyring().Seal(plaintext, m.AdditionalData)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Ciphertext, result.Changed = upgraded, true
+	return result
+}
+
+// ReEncryptBatch 批量迁移，结果与输入一一对应，单个失败不影响其他密文
+// 调用方只需把 Changed 为true的结果写回存储，可以分批多次执行
+func (m *Migrator) ReEncryptBatch(ciphertexts []string) []MigrateResult {
+	results := make([]MigrateResult, 0, len(ciphertexts))
+	for _, ciphertext := range ciphertexts {
+		results = append(results, m.ReEncrypt(ciphertext))
+	}
+	return results
+}
+
+func (m *Migrator) keyring() *Keyring {
+	if m.Keyring == nil {
+		return emptyKeyring
+	}
+	return m.Keyring
+}
+
+// emptyKeyring Migrator 未配置密钥环时使用，所有操作返回 ErrKeyNotFound
+var emptyKeyring = NewKeyring()
@@ -0,0 +1,40 @@
+package encipherment
+
+import (
+	"testing"
+)
+
+func TestMigrator(t *testing.T) {
+	legacyKey := "launcherSoNBPlus"
+	keyring := NewKeyring()
+	_ = keyring.Add("2023", []byte("0123456789abcdef0123456789abcdef"))
+	migrator := &Migrator{LegacyKey: []byte(legacyKey), Keyring: keyring}
+
+	legacy, _ := AesEncrypt("hello", legacyKey)
+	current, _ := keyring.Seal([]byte("world"), nil)
+
+	plaintext, format, err := DecryptAny(legacy, []byte(legacyKey), nil)
+	if err != nil || format != FormatLegacy || string(plaintext) != "hello" {
+		t.Fatal(format, err)
+	}
+	plaintext, format, err = migrator.Decrypt(current)
+	if err != nil || format != FormatEnvelope || string(plaintext) != "world" {
+		t.Fatal(format, err)
+	}
+
+	results := migrator.ReEncryptBatch([]string{legacy, current, "broken"})
+	if !results[0].Changed || results[0].Format != FormatLegacy || results[0].Err != nil {
+		t.Fatal(results[0])
+	}
+	if results[1].Changed || results[1].Ciphertext != current || results[1].Err != nil {
+		t.Fatal(results[1])
+	}
+	if results[2].Err == nil || results[2].Ciphertext != "broken" {
+		t.Fatal(results[2])
+	}
+
+	plaintext, format, err = migrator.Decrypt(results[0].Ciphertext)
+	if err != nil || format != FormatEnvelope || string(plaintext) != "hello" {
+		t.Fatal(format, err)
+	}
+}
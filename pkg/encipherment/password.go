@@ -0,0 +1,131 @@
+package encipherment
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// PasswordBcrypt bcrypt，哈希格式为 $2a$<cost>$...
+	PasswordBcrypt = "bcrypt"
+	// PasswordScrypt scrypt，哈希格式为 $scrypt$ln=15,r=8,p=1,l=32$<salt>$<hash>
+	PasswordScrypt = "scrypt"
+)
+
+// PasswordParams 密码哈希参数，参数会编码进哈希值，因此提高开销后旧哈希仍可校验
+type PasswordParams struct {
+	// Algorithm 哈希算法，PasswordBcrypt 或 PasswordScrypt
+	Algorithm string
+	// BcryptCost bcrypt 开销，取值 4~31
+	BcryptCost int
+	// ScryptN scrypt CPU/内存开销参数，必须是大于1的2的幂
+	ScryptN int
+	// ScryptR scrypt 块大小参数
+	ScryptR int
+	// ScryptP scrypt 并行参数
+	ScryptP int
+}
+
+// DefaultPasswordParams 默认的密码哈希参数
+func DefaultPasswordParams() *PasswordParams {
+	return &PasswordParams{Algorithm: PasswordBcrypt, BcryptCost: 12,
+		ScryptN: defaultScryptN, ScryptR: defaultScryptR, ScryptP: defaultScryptP}
+}
+
+// HashPassword 计算密码哈希，params 为nil时使用 DefaultPasswordParams
+func HashPassword(password string, params *PasswordParams) (string, error) {
+	if params == nil {
+		params = DefaultPasswordParams()
+	}
+	switch params.Algorithm {
+	case PasswordBcrypt:
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), params.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hash), nil
+	case PasswordScrypt:
+		salt, err := randomBytes(defaultSaltLen)
+		if err != nil {
+			return "", err
+		}
+		derived, err := DeriveKey(password, &KDFParams{Algorithm: KDFScrypt, Salt: salt,
+			N: params.ScryptN, R: params.ScryptR, P: params.ScryptP, KeyLen: defaultKeyLen})
+		if err != nil {
+			return "", err
+		}
+		return derived.Params.String() + "$" + base64.RawStdEncoding.EncodeToString(derived.Key), nil
+	}
+	return "", fmt.Errorf("unsupported password algorithm: %s", params.Algorithm)
+}
+
+// VerifyPassword 校验密码，密码不正确时返回 ErrPasswordMismatch
+func VerifyPassword(hash, password string) error {
+	if strings.HasPrefix(hash, "$"+PasswordScrypt+"$") {
+		params, expected, err := parseScryptHash(hash)
+		if err != nil {
+			return err
+		}
+		derived, err := DeriveKey(password, params)
+		if err != nil {
+			return err
+		}
+		if subtle.ConstantTimeCompare(derived.Key, expected) != 1 {
+			return ErrPasswordMismatch
+		}
+		return nil
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrPasswordMismatch
+	}
+	return err
+}
+
+// NeedsRehash 判断哈希是否需要按新参数重新计算：算法不同或开销低于 params 时返回true
+// 通常在登录校验密码成功后调用，需要时用明文密码重新计算并保存哈希
+func NeedsRehash(hash string, params *PasswordParams) bool {
+	if params == nil {
+		params = DefaultPasswordParams()
+	}
+	if strings.HasPrefix(hash, "$"+PasswordScrypt+"$") {
+		if params.Algorithm != PasswordScrypt {
+			return true
+		}
+		p, _, err := parseScryptHash(hash)
+		if err != nil {
+			return true
+		}
+		return p.N < params.ScryptN || p.R < params.ScryptR || p.P < params.ScryptP
+	}
+	if params.Algorithm != PasswordBcrypt {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return true
+	}
+	return cost < params.BcryptCost
+}
+
+// parseScryptHash 解析scrypt哈希，返回派生参数与哈希值
+func parseScryptHash(hash string) (*KDFParams, []byte, error) {
+	index := strings.LastIndex(hash, "$")
+	params, err := ParseKDFParams(hash[:index])
+	if err != nil {
+		return nil, nil, err
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(hash[index+1:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrInvalidEncoding, err.Error())
+	}
+	if len(expected) != params.KeyLen {
+		return nil, nil, fmt.Errorf("invalid scrypt hash length: %d", len(expected))
+	}
+	return params, expected, nil
+}
@@ -0,0 +1,41 @@
+package encipherment
+
+import (
+	"testing"
+)
+
+func TestHashPassword(t *testing.T) {
+	weak := []*PasswordParams{
+		{Algorithm: PasswordBcrypt, BcryptCost: 4},
+		{Algorithm: PasswordScrypt, ScryptN: 1 << 10, ScryptR: 8, ScryptP: 1},
+	}
+	for _, params := range weak {
+		hash, err := HashPassword("p@ssw0rd", params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = VerifyPassword(hash, "p@ssw0rd"); err != nil {
+			t.Fatal(hash, err)
+		}
+		if err = VerifyPassword(hash, "wrong"); err != ErrPasswordMismatch {
+			t.Fatal(hash, err)
+		}
+		if NeedsRehash(hash, params) {
+			t.Fatal(hash)
+		}
+	}
+
+	//提高开销
+	bcryptHash, _ := HashPassword("p@ssw0rd", weak[0])
+	if !NeedsRehash(bcryptHash, &PasswordParams{Algorithm: PasswordBcrypt, BcryptCost: 5}) {
+		t.Fatal(bcryptHash)
+	}
+	scryptHash, _ := HashPassword("p@ssw0rd", weak[1])
+	if !NeedsRehash(scryptHash, &PasswordParams{Algorithm: PasswordScrypt, ScryptN: 1 << 11, ScryptR: 8, ScryptP: 1}) {
+		t.Fatal(scryptHash)
+	}
+	//切换算法
+	if !NeedsRehash(scryptHash, weak[0]) || !NeedsRehash(bcryptHash, weak[1]) {
+		t.Fatal("algorithm changed")
+	}
+}
@@ -0,0 +1,177 @@
+package encipherment
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"fmt"
+)
+
+// AlgorithmAesSiv AES-SIV(RFC 5297) 算法名称，确定性加密
+// key 长度必须为 32/48/64 字节，前一半用于S2V，后一半用于CTR
+const AlgorithmAesSiv = "aes-siv"
+
+// SealDeterministic 使用AES-SIV进行确定性加密：相同的key、明文与附加数据总是得到相同的密文
+// 适用于需要按密文做等值查询的加密列，同时提供完整性校验
+// 确定性加密会暴露明文是否相同，不需要等值查询时应使用 Seal
+func SealDeterministic(plaintext, key, additionalData []byte) (string, error) {
+	return Encrypt(AlgorithmAesSiv, plaintext, key, additionalData)
+}
+
+// OpenDeterministic 解密 SealDeterministic 生成的密文
+func OpenDeterministic(ciphertext string, key, additionalData []byte) ([]byte, error) {
+	return Decrypt(ciphertext, key, additionalData)
+}
+
+// SealDeterministic 使用主密钥进行AES-SIV确定性加密，主密钥长度必须为32字节
+// 轮换主密钥后，相同明文的密文会变化，等值查询前需要先用 ReEncrypt 迁移已有数据，迁移后仍为AES-SIV密文
+func (k *Keyring) SealDeterministic(plaintext, additionalData []byte) (string, error) {
+	id, key, err := k.key("")
+	if err != nil {
+		return "", err
+	}
+	return sealEnvelope(&envelope{algorithm: AlgorithmAesSiv, keyID: id}, key, plaintext, additionalData)
+}
+
+// aesSivCipher AES-SIV 的 Cipher 实现，payload 为 V || C
+type aesSivCipher struct{}
+
+func (aesSivCipher) Name() string {
+	return AlgorithmAesSiv
+}
+
+func (aesSivCipher) Encrypt(key, plaintext, additionalData []byte) ([]byte, error) {
+	return sivSeal(key, plaintext, additionalData)
+}
+
+func (aesSivCipher) Decrypt(key, payload, additionalData []byte) ([]byte, error) {
+	return sivOpen(key, payload, additionalData)
+}
+
+// sivSeal RFC 5297 加密，additionalData 为S2V的多个附加数据分量
+func sivSeal(key, plaintext []byte, additionalData ...[]byte) ([]byte, error) {
+	macBlock, ctrBlock, err := newSivBlocks(key)
+	if err != nil {
+		return nil, err
+	}
+	v := s2v(macBlock, append(append([][]byte(nil), additionalData...), plaintext))
+	out := make([]byte, aes.BlockSize+len(plaintext))
+	copy(out, v)
+	sivCtr(ctrBlock, v, out[aes.BlockSize:], plaintext)
+	return out, nil
+}
+
+// sivOpen RFC 5297 解密
+func sivOpen(key, payload []byte, additionalData ...[]byte) ([]byte, error) {
+	macBlock, ctrBlock, err := newSivBlocks(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) < aes.BlockSize {
+		return nil, ErrInvalidEnvelope
+	}
+	v := payload[:aes.BlockSize]
+	plaintext := make([]byte, len(payload)-aes.BlockSize)
+	sivCtr(ctrBlock, v, plaintext, payload[aes.BlockSize:])
+	if subtle.ConstantTimeCompare(v, s2v(macBlock, append(append([][]byte(nil), additionalData...), plaintext))) != 1 {
+		return nil, fmt.Errorf("cipher: message authentication failed")
+	}
+	return plaintext, nil
+}
+
+func newSivBlocks(key []byte) (cipher.Block, cipher.Block, error) {
+	switch len(key) {
+	case 32, 48, 64:
+	default:
+		return nil, nil, fmt.Errorf("%w: aes-siv key length must be 32/48/64, got %d", ErrInvalidKey, len(key))
+	}
+	macBlock, err := newAesBlock(key[:len(key)/2])
+	if err != nil {
+		return nil, nil, err
+	}
+	ctrBlock, err := newAesBlock(key[len(key)/2:])
+	if err != nil {
+		return nil, nil, err
+	}
+	return macBlock, ctrBlock, nil
+}
+
+// sivCtr 使用V清除第31与63位后作为计数器初始值进行CTR运算
+func sivCtr(block cipher.Block, v, dst, src []byte) {
+	iv := make([]byte, aes.BlockSize)
+	copy(iv, v)
+	iv[8] &= 0x7f
+	iv[12] &= 0x7f
+	cipher.NewCTR(block, iv).XORKeyStream(dst, src)
+}
+
+// s2v RFC 5297 S2V，components 的最后一个元素为明文
+func s2v(block cipher.Block, components [][]byte) []byte {
+	d := cmac(block, make([]byte, aes.BlockSize))
+	for _, s := range components[:len(components)-1] {
+		dbl(d)
+		xorBytes(d, cmac(block, s))
+	}
+	last := components[len(components)-1]
+	var t []byte
+	if len(last) >= aes.BlockSize {
+		t = append([]byte(nil), last...)
+		xorBytes(t[len(t)-aes.BlockSize:], d)
+	} else {
+		dbl(d)
+		t = make([]byte, aes.BlockSize)
+		copy(t, last)
+		t[len(last)] = 0x80
+		xorBytes(t, d)
+	}
+	return cmac(block, t)
+}
+
+// cmac RFC 4493 AES-CMAC
+func cmac(block cipher.Block, message []byte) []byte {
+	k1 := make([]byte, aes.BlockSize)
+	block.Encrypt(k1, k1)
+	dbl(k1)
+	k2 := append([]byte(nil), k1...)
+	dbl(k2)
+
+	n := (len(message) + aes.BlockSize - 1) / aes.BlockSize
+	complete := n > 0 && len(message)%aes.BlockSize == 0
+	if n == 0 {
+		n = 1
+	}
+	last := make([]byte, aes.BlockSize)
+	if complete {
+		copy(last, message[(n-1)*aes.BlockSize:])
+		xorBytes(last, k1)
+	} else {
+		rest := message[(n-1)*aes.BlockSize:]
+		copy(last, rest)
+		last[len(rest)] = 0x80
+		xorBytes(last, k2)
+	}
+
+	x := make([]byte, aes.BlockSize)
+	for i := 0; i < n-1; i++ {
+		xorBytes(x, message[i*aes.BlockSize:(i+1)*aes.BlockSize])
+		block.Encrypt(x, x)
+	}
+	xorBytes(x, last)
+	block.Encrypt(x, x)
+	return x
+}
+
+// dbl GF(2^128) 上乘以x
+func dbl(b []byte) {
+	carry := b[0] >> 7
+	for i := 0; i < len(b)-1; i++ {
+		b[i] = b[i]<<1 | b[i+1]>>7
+	}
+	b[len(b)-1] = b[len(b)-1]<<1 ^ 0x87*carry
+}
+
+func xorBytes(dst, src []byte) {
+	for i := range src {
+		dst[i] ^= src[i]
+	}
+}
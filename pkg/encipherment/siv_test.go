@@ -0,0 +1,126 @@
+package encipherment
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func unhex(s string) []byte {
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// TestSivVectors RFC 5297 附录A的测试向量
+func TestSivVectors(t *testing.T) {
+	cases := []struct {
+		key, plaintext, output string
+		ad                     []string
+	}{
+		{
+			key:       "fffefdfc fbfaf9f8 f7f6f5f4 f3f2f1f0 f0f1f2f3 f4f5f6f7 f8f9fafb fcfdfeff",
+			ad:        []string{"10111213 14151617 18191a1b 1c1d1e1f 20212223 24252627"},
+			plaintext: "11223344 55667788 99aabbcc ddee",
+			output:    "85632d07 c6e8f37f 950acd32 0a2ecc93 40c02b96 90c4dc04 daef7f6a fe5c",
+		},
+		{
+			key: "7f7e7d7c 7b7a7978 77767574 73727170 40414243 44454647 48494a4b 4c4d4e4f",
+			ad: []string{
+				"00112233 44556677 8899aabb ccddeeff deaddada deaddada ffeeddcc bbaa9988 77665544 33221100",
+				"10203040 50607080 90a0",
+				"09f91102 9d74e35b d84156c5 635688c0",
+			},
+			plaintext: "74686973 20697320 736f6d65 20706c61 696e7465 78742074 6f20656e 63727970 74207573 696e6720 5349562d 414553",
+			output: "7bdb6e3b 432667eb 06f4d14b ff2fbd0f cb900f2f ddbe4043 26601965 c889bf17 dba77ceb 094fa663 b7a3f748 ba8af829" +
+				" ea64ad54 4a272e9c 485b62a3 fd5c0d",
+		},
+	}
+	for _, c := range cases {
+		var ad [][]byte
+		for _, a := range c.ad {
+			ad = append(ad, unhex(a))
+		}
+		output, err := sivSeal(unhex(c.key), unhex(c.plaintext), ad...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(output, unhex(c.output)) {
+			t.Fatalf("expect %s, got %x", c.output, output)
+		}
+		plaintext, err := sivOpen(unhex(c.key), output, ad...)
+		if err != nil || !bytes.Equal(plaintext, unhex(c.plaintext)) {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSealDeterministic(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	first, err := SealDeterministic([]byte("13800138000"), key, []byte("phone"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := SealDeterministic([]byte("13800138000"), key, []byte("phone"))
+	if first != second {
+		t.Fatal("deterministic ciphertext differs")
+	}
+	other, _ := SealDeterministic([]byte("13800138000"), key, []byte("mobile"))
+	if first == other {
+		t.Fatal("additional data is not bound")
+	}
+	plaintext, err := OpenDeterministic(first, key, []byte("phone"))
+	if err != nil || string(plaintext) != "13800138000" {
+		t.Fatal(err)
+	}
+	if _, err = OpenDeterministic(first, key, []byte("mobile")); err == nil {
+		t.Fatal("expected error for wrong additional data")
+	}
+}
+
+func TestKeyringDeterministicRotate(t *testing.T) {
+	keyring := NewKeyring()
+	_ = keyring.Add("k1", []byte("0123456789abcdef0123456789abcdef"))
+	first, _ := keyring.SealDeterministic([]byte("13800138000"), []byte("phone"))
+	second, _ := keyring.SealDeterministic([]byte("13800138000"), []byte("phone"))
+	if first != second {
+		t.Fatal("deterministic ciphertext differs")
+	}
+
+	if err := keyring.Rotate("k2", []byte("abcdef0123456789abcdef0123456789")); err != nil {
+		t.Fatal(err)
+	}
+	migrator := &Migrator{Keyring: keyring, AdditionalData: []byte("phone")}
+	results := migrator.ReEncryptBatch([]string{first, second})
+	for _, result := range results {
+		if result.Err != nil || !result.Changed {
+			t.Fatal(result.Changed, result.Err)
+		}
+		e, _ := parseEnvelope(result.Ciphertext)
+		if e.algorithm != AlgorithmAesSiv || e.keyID != "k2" {
+			t.Fatal(e.algorithm, e.keyID)
+		}
+	}
+	//迁移后相同明文的密文仍然相同，且与新主密钥加密的密文相同
+	if results[0].Ciphertext != results[1].Ciphertext {
+		t.Fatal("deterministic ciphertext differs after rotation")
+	}
+	lookup, _ := keyring.SealDeterministic([]byte("13800138000"), []byte("phone"))
+	if lookup != results[0].Ciphertext {
+		t.Fatal("lookup ciphertext differs after rotation")
+	}
+	if _, changed, err := keyring.ReEncrypt(lookup, []byte("phone")); err != nil || changed {
+		t.Fatal(changed, err)
+	}
+
+	//篡改为aes-cbc的密文不能通过 OpenDeterministic 解密
+	e, _ := parseEnvelope(first)
+	e.algorithm, e.keyID = AlgorithmAesCbc, ""
+	e.payload, _ = cbcEncrypt([]byte("0123456789abcdef0123456789abcdef"), []byte("forged"))
+	if _, err := OpenDeterministic(e.String(), []byte("0123456789abcdef0123456789abcdef"), []byte("phone")); err == nil {
+		t.Fatal("expected error when opening tampered aes-cbc envelope")
+	}
+}
@@ -0,0 +1,235 @@
+package encipherment
+
+import (
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// streamVersion 流式加密格式版本
+	streamVersion = 1
+	// streamChunkSize 每个分块的明文长度
+	streamChunkSize = 64 * 1024
+	streamSaltSize  = 16
+	// streamPrefixSize nonce前缀长度，nonce = 前缀(7) || 分块序号(4) || 结束标记(1)
+	streamPrefixSize = 7
+	streamHeaderSize = 1 + streamSaltSize + streamPrefixSize
+	streamInfo       = "encipherment stream v1"
+)
+
+// NewEncryptWriter 流式加密，写入的明文按64KB分块，使用AES-GCM加密后写入w
+// key length must 16, 24, or 32 bytes to select
+// 每个分块的nonce包含分块序号与结束标记，解密时可以发现分块被截断、重排或篡改
+// 写入完成后必须调用 Close 写入最后一个分块，Close 不会关闭w
+func NewEncryptWriter(w io.Writer, key []byte) (io.WriteCloser, error) {
+	header := make([]byte, streamHeaderSize)
+	header[0] = streamVersion
+	salt, err := randomBytes(streamSaltSize + streamPrefixSize)
+	if err != nil {
+		return nil, err
+	}
+	copy(header[1:], salt)
+	aead, err := newStreamAead(key, header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		w:      w,
+		stream: newStreamNonce(aead, header),
+		buf:    make([]byte, 0, streamChunkSize),
+	}, nil
+}
+
+// NewDecryptReader 流式解密 NewEncryptWriter 写入的数据
+// 数据被截断、重排或篡改时，Read 返回 ErrInvalidStream
+func NewDecryptReader(r io.Reader, key []byte) (io.Reader, error) {
+	header := make([]byte, streamHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidStream, err.Error())
+	}
+	if header[0] != streamVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidStream, header[0])
+	}
+	aead, err := newStreamAead(key, header)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{
+		r:      r,
+		stream: newStreamNonce(aead, header),
+		buf:    make([]byte, 0, streamChunkSize+aead.Overhead()+1),
+		out:    make([]byte, 0, streamChunkSize),
+	}, nil
+}
+
+// newStreamAead 使用HKDF从key与随机盐值派生出本次流使用的子密钥
+func newStreamAead(key, header []byte) (cipher.AEAD, error) {
+	if _, err := newAesBlock(key); err != nil {
+		return nil, err
+	}
+	subKey := make([]byte, len(key))
+	kdf := hkdf.New(sha256.New, key, header[1:1+streamSaltSize], []byte(streamInfo))
+	if _, err := io.ReadFull(kdf, subKey); err != nil {
+		return nil, err
+	}
+	return newGcm(subKey)
+}
+
+// streamNonce 分块加解密，维护分块序号
+type streamNonce struct {
+	aead    cipher.AEAD
+	header  []byte
+	nonce   []byte
+	counter uint32
+}
+
+func newStreamNonce(aead cipher.AEAD, header []byte) *streamNonce {
+	nonce := make([]byte, aead.NonceSize())
+	copy(nonce, header[1+streamSaltSize:])
+	return &streamNonce{aead: aead, header: header, nonce: nonce}
+}
+
+// next 返回下一个分块的nonce
+func (s *streamNonce) next(last bool) ([]byte, error) {
+	if s.counter == ^uint32(0) {
+		return nil, errors.New("stream too large")
+	}
+	binary.BigEndian.PutUint32(s.nonce[streamPrefixSize:], s.counter)
+	s.nonce[len(s.nonce)-1] = 0
+	if last {
+		s.nonce[len(s.nonce)-1] = 1
+	}
+	s.counter++
+	return s.nonce, nil
+}
+
+// encryptWriter NewEncryptWriter 的实现
+type encryptWriter struct {
+	w      io.Writer
+	stream *streamNonce
+	// buf 未加密的明文，最多一个分块
+	buf    []byte
+	closed bool
+	err    error
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	if e.closed {
+		return 0, errors.New("write to closed stream")
+	}
+	n := 0
+	for len(p) > 0 {
+		//缓冲区已满且还有数据时，才能确定当前分块不是最后一个
+		if len(e.buf) == streamChunkSize {
+			if e.err = e.flush(false); e.err != nil {
+				return n, e.err
+			}
+		}
+		size := streamChunkSize - len(e.buf)
+		if size > len(p) {
+			size = len(p)
+		}
+		e.buf = append(e.buf, p[:size]...)
+		p = p[size:]
+		n += size
+	}
+	return n, nil
+}
+
+// Close 加密并写入最后一个分块
+func (e *encryptWriter) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	e.err = e.flush(true)
+	return e.err
+}
+
+func (e *encryptWriter) flush(last bool) error {
+	nonce, err := e.stream.next(last)
+	if err != nil {
+		return err
+	}
+	sealed := e.stream.aead.Seal(nil, nonce, e.buf, e.stream.header)
+	e.buf = e.buf[:0]
+	_, err = e.w.Write(sealed)
+	return err
+}
+
+// decryptReader NewDecryptReader 的实现
+type decryptReader struct {
+	r      io.Reader
+	stream *streamNonce
+	// buf 读取的密文，多读1个字节用于判断是否为最后一个分块
+	buf []byte
+	// out 解密使用的缓冲区
+	out []byte
+	// plain 已解密未读取的明文
+	plain []byte
+	done  bool
+	err   error
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.done {
+			return 0, io.EOF
+		}
+		d.err = d.readChunk()
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+func (d *decryptReader) readChunk() error {
+	segment := streamChunkSize + d.stream.aead.Overhead()
+	n, err := io.ReadFull(d.r, d.buf[len(d.buf):segment+1])
+	d.buf = d.buf[:len(d.buf)+n]
+	last := false
+	switch err {
+	case nil:
+	case io.EOF, io.ErrUnexpectedEOF:
+		last = true
+	default:
+		return err
+	}
+	chunk := d.buf
+	if !last {
+		chunk = d.buf[:segment]
+	}
+	nonce, err := d.stream.next(last)
+	if err != nil {
+		return err
+	}
+	d.plain, err = d.stream.aead.Open(d.out[:0], nonce, chunk, d.stream.header)
+	if err != nil {
+		return ErrInvalidStream
+	}
+	if last {
+		d.done = true
+		return nil
+	}
+	//保留多读的1个字节，作为下一个分块的开头
+	d.buf = append(d.buf[:0], d.buf[segment])
+	return nil
+}
@@ -0,0 +1,75 @@
+package encipherment
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func TestEncryptStream(t *testing.T) {
+	key := []byte("launcherSoNBPlus")
+	for _, size := range []int{0, 1, streamChunkSize, streamChunkSize + 1, 3*streamChunkSize + 100} {
+		orig := make([]byte, size)
+		_, _ = rand.Read(orig)
+
+		encrypted := &bytes.Buffer{}
+		w, err := NewEncryptWriter(encrypted, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		//分多次写入
+		if _, err = io.CopyBuffer(w, bytes.NewReader(orig), make([]byte, 1000)); err != nil {
+			t.Fatal(err)
+		}
+		if err = w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := NewDecryptReader(bytes.NewReader(encrypted.Bytes()), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decrypted, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(size, err)
+		}
+		if !bytes.Equal(decrypted, orig) {
+			t.Fatal(size)
+		}
+	}
+}
+
+func TestDecryptStreamTampered(t *testing.T) {
+	key := []byte("launcherSoNBPlus")
+	orig := make([]byte, 2*streamChunkSize+10)
+	encrypted := &bytes.Buffer{}
+	w, _ := NewEncryptWriter(encrypted, key)
+	_, _ = w.Write(orig)
+	_ = w.Close()
+	data := encrypted.Bytes()
+	segment := streamChunkSize + 16
+
+	//截断最后一个分块
+	truncated := data[:streamHeaderSize+2*segment]
+	//交换前两个分块
+	reordered := append([]byte(nil), data[:streamHeaderSize]...)
+	reordered = append(reordered, data[streamHeaderSize+segment:streamHeaderSize+2*segment]...)
+	reordered = append(reordered, data[streamHeaderSize:streamHeaderSize+segment]...)
+	reordered = append(reordered, data[streamHeaderSize+2*segment:]...)
+	//修改一个字节
+	modified := append([]byte(nil), data...)
+	modified[len(modified)-1] ^= 1
+
+	for _, bad := range [][]byte{truncated, reordered, modified} {
+		r, err := NewDecryptReader(bytes.NewReader(bad), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = ioutil.ReadAll(r); !errors.Is(err, ErrInvalidStream) {
+			t.Fatal(err)
+		}
+	}
+}
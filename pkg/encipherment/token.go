@@ -0,0 +1,172 @@
+package encipherment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// ClaimExpiresAt 过期时间，unix秒
+	ClaimExpiresAt = "exp"
+	// ClaimIssuedAt 签发时间，unix秒
+	ClaimIssuedAt = "iat"
+	// ClaimNotBefore 生效时间，unix秒，可选
+	ClaimNotBefore = "nbf"
+
+	tokenAlgorithm = "HS256"
+	// DefaultClockSkew 默认允许的时钟偏差
+	DefaultClockSkew = time.Minute
+)
+
+// Claims token中携带的声明
+type Claims map[string]interface{}
+
+// tokenHeader token头部，与JWT兼容
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// TokenSigner 使用HMAC-SHA256签发与校验带有效期的token，适用于放在url中的临时链接
+// token 格式与JWT(HS256)兼容：base64url(header).base64url(claims).base64url(signature)
+// 签名使用密钥环的主密钥，并在头部写入密钥ID，校验时按密钥ID选择密钥，因此轮换密钥后已签发的token仍然有效
+type TokenSigner struct {
+	keyring *Keyring
+	// ClockSkew 校验时间声明时允许的时钟偏差
+	ClockSkew time.Duration
+	// Now 获取当前时间，默认为 time.Now
+	Now func() time.Time
+}
+
+// NewTokenSigner 构建 TokenSigner，时钟偏差默认为 DefaultClockSkew
+func NewTokenSigner(keyring *Keyring) *TokenSigner {
+	return &TokenSigner{keyring: keyring, ClockSkew: DefaultClockSkew, Now: time.Now}
+}
+
+// Sign 签发token，claims 会被复制并写入签发时间与过期时间，ttl 必须大于0
+func (s *TokenSigner) Sign(claims Claims, ttl time.Duration) (string, error) {
+	if ttl <= 0 {
+		return "", fmt.Errorf("invalid token ttl: %s", ttl)
+	}
+	kid, key, err := s.keyring.key("")
+	if err != nil {
+		return "", err
+	}
+	now := s.now()
+	payload := make(Claims, len(claims)+2)
+	for k, v := range claims {
+		payload[k] = v
+	}
+	payload[ClaimIssuedAt] = now.Unix()
+	payload[ClaimExpiresAt] = now.Add(ttl).Unix()
+
+	header, err := json.Marshal(tokenHeader{Alg: tokenAlgorithm, Typ: "JWT", Kid: kid})
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(tokenMac(key, signingInput)), nil
+}
+
+// Verify 校验token的签名与时间声明，返回token中的声明
+// 签名错误返回 ErrInvalidSignature，过期返回 ErrTokenExpired，未生效返回 ErrTokenNotValidYet
+func (s *TokenSigner) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+	header := tokenHeader{}
+	if err := decodeTokenPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header.Alg != tokenAlgorithm {
+		return nil, fmt.Errorf("%w: unsupported algorithm %s", ErrInvalidToken, header.Alg)
+	}
+	_, key, err := s.keyring.key(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidEncoding, err.Error())
+	}
+	if !hmac.Equal(signature, tokenMac(key, parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidSignature
+	}
+
+	claims := Claims{}
+	if err = decodeTokenPart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	now := s.now()
+	exp, ok := claims.unix(ClaimExpiresAt)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing %s claim", ErrInvalidToken, ClaimExpiresAt)
+	}
+	if now.After(exp.Add(s.ClockSkew)) {
+		return nil, ErrTokenExpired
+	}
+	for _, name := range []string{ClaimIssuedAt, ClaimNotBefore} {
+		if t, ok := claims.unix(name); ok && t.After(now.Add(s.ClockSkew)) {
+			return nil, ErrTokenNotValidYet
+		}
+	}
+	return claims, nil
+}
+
+// ExpiresAt 返回过期时间
+func (c Claims) ExpiresAt() (time.Time, bool) {
+	return c.unix(ClaimExpiresAt)
+}
+
+// IssuedAt 返回签发时间
+func (c Claims) IssuedAt() (time.Time, bool) {
+	return c.unix(ClaimIssuedAt)
+}
+
+// unix 读取unix秒格式的时间声明
+func (c Claims) unix(name string) (time.Time, bool) {
+	switch v := c[name].(type) {
+	case float64:
+		return time.Unix(int64(v), 0), true
+	case int64:
+		return time.Unix(v, 0), true
+	case int:
+		return time.Unix(int64(v), 0), true
+	}
+	return time.Time{}, false
+}
+
+func (s *TokenSigner) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+func tokenMac(key []byte, signingInput string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
+
+func decodeTokenPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidEncoding, err.Error())
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidToken, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package encipherment
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenSigner(t *testing.T) {
+	keyring := NewKeyring()
+	_ = keyring.Add("2022", []byte("0123456789abcdef0123456789abcdef"))
+	signer := NewTokenSigner(keyring)
+	now := time.Unix(1670000000, 0)
+	signer.Now = func() time.Time { return now }
+
+	token, err := signer.Sign(Claims{"file": "report.xml"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := signer.Verify(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims["file"] != "report.xml" {
+		t.Fatal(claims)
+	}
+	if exp, _ := claims.ExpiresAt(); !exp.Equal(now.Add(time.Hour)) {
+		t.Fatal(exp)
+	}
+
+	//轮换密钥后旧token仍然有效
+	_ = keyring.Rotate("2023", []byte("abcdef0123456789abcdef0123456789"))
+	if _, err = signer.Verify(token); err != nil {
+		t.Fatal(err)
+	}
+
+	//篡改声明
+	parts := []byte(token)
+	parts[40] ^= 1
+	if _, err = signer.Verify(string(parts)); err == nil {
+		t.Fatal("expected error for tampered token")
+	}
+
+	//时钟偏差内仍有效
+	now = now.Add(time.Hour + 30*time.Second)
+	if _, err = signer.Verify(token); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Minute)
+	if _, err = signer.Verify(token); !errors.Is(err, ErrTokenExpired) {
+		t.Fatal(err)
+	}
+
+	//签发时间在未来
+	now = time.Unix(1670000000, 0).Add(-2 * time.Minute)
+	if _, err = signer.Verify(token); !errors.Is(err, ErrTokenNotValidYet) {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,384 @@
+package file
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+var _ Client = &FakeClient{}
+
+// FakeCall 记录的一次调用
+type FakeCall struct {
+	// Method 参数描述：方法名，例如 UploadFile
+	Method string
+	// Keys 参数描述：调用涉及的文件名，Copy 与 Move 为源文件与目标文件，List 为前缀
+	Keys []string
+}
+
+// FakeClient 内存中的 Client 实现，用于依赖 Client 的代码在没有云服务商凭证时进行单元测试
+// 复制到其他桶的对象可以通过 Bucket 读取或预置
+//
+//	client := file.NewFakeClient("bucket")
+//	client.SetError("Stat", "broken.txt", errors.New("timeout"))
+//	service := NewService(client)
+//	...
+//	calls := client.Calls()
+//	exists, _ := client.Bucket("backup").Exists("report.csv")
+type FakeClient struct {
+	// BucketName 当前桶名，CopyOptions.DestBucket 为空时复制到该桶
+	BucketName string
+
+	*fakeStore
+}
+
+// fakeStore 所有桶共享的存储、调用记录与注入的错误
+type fakeStore struct {
+	lock    sync.Mutex
+	buckets map[string]map[string]*fakeObject
+	calls   []FakeCall
+	errors  map[string]error
+}
+
+// fakeObject 内存中的对象
+type fakeObject struct {
+	content      []byte
+	options      UploadOptions
+	eTag         string
+	lastModified time.Time
+}
+
+// NewFakeClient
+/**
+ * 功能描述：创建内存中的 Client
+ * @param bucketName 桶名，用于签名url与复制
+ * @return *FakeClient
+ */
+func NewFakeClient(bucketName string) *FakeClient {
+	return &FakeClient{BucketName: bucketName,
+		fakeStore: &fakeStore{buckets: map[string]map[string]*fakeObject{}, errors: map[string]error{}}}
+}
+
+// Bucket
+/**
+ * 功能描述：返回操作指定桶的 Client，与当前 Client 共享存储、调用记录与注入的错误，用于读取复制到其他桶的对象或预置对象
+ * @param bucketName 桶名
+ * @return *FakeClient
+ */
+func (fake *FakeClient) Bucket(bucketName string) *FakeClient {
+	return &FakeClient{BucketName: bucketName, fakeStore: fake.fakeStore}
+}
+
+// SetError
+/**
+ * 功能描述：注入错误，调用指定方法且涉及指定文件时返回该错误，err为nil时取消注入
+ * @param method 方法名，为空时匹配所有方法
+ * @param fileName 文件名，为空时匹配所有文件
+ * @param err 返回的错误
+ */
+func (fake *FakeClient) SetError(method, fileName string, err error) {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	key := method + "\n" + fileName
+	if err == nil {
+		delete(fake.errors, key)
+		return
+	}
+	fake.errors[key] = err
+}
+
+// Calls 返回所有调用记录
+func (fake *FakeClient) Calls() []FakeCall {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	calls := make([]FakeCall, len(fake.calls))
+	copy(calls, fake.calls)
+	return calls
+}
+
+// ResetCalls 清空调用记录
+func (fake *FakeClient) ResetCalls() {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	fake.calls = nil
+}
+
+// Close 只记录调用
+func (fake *FakeClient) Close() {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	fake.record("Close")
+}
+
+// DownloadFile 将对象内容写入本地文件
+func (fake *FakeClient) DownloadFile(fileName, localFile string) (string, error) {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	if err := fake.record("DownloadFile", fileName); err != nil {
+		return "", err
+	}
+	object, err := fake.object(fake.BucketName, fileName)
+	if err != nil {
+		return "", err
+	}
+	if err = ioutil.WriteFile(localFile, object.content, 0644); err != nil {
+		return "", err
+	}
+	return localFile, nil
+}
+
+// CreateSignedUrl 返回带过期时间的url，不校验对象是否存在
+func (fake *FakeClient) CreateSignedUrl(fileName string, expires int) (string, error) {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	if err := fake.record("CreateSignedUrl", fileName); err != nil {
+		return "", err
+	}
+	expiresAt := time.Now().Add(time.Duration(expires) * time.Second).Unix()
+	query := url.Values{}
+	query.Set("Expires", strconv.FormatInt(expiresAt, 10))
+	return fmt.Sprintf("https://%s.fake.local/%s?%s", fake.BucketName, escapeKey(fileName), query.Encode()), nil
+}
+
+// DeleteFiles 删除对象，不存在的对象会被忽略
+func (fake *FakeClient) DeleteFiles(fileNames []string) error {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	if err := fake.record("DeleteFiles", fileNames...); err != nil {
+		return err
+	}
+	for _, fileName := range fileNames {
+		delete(fake.buckets[fake.BucketName], fileName)
+	}
+	return nil
+}
+
+// UploadFile 保存对象
+func (fake *FakeClient) UploadFile(fileName string, content []byte) error {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	if err := fake.record("UploadFile", fileName); err != nil {
+		return err
+	}
+	fake.put(fake.BucketName, fileName, content, nil)
+	return nil
+}
+
+// UploadStream 读取reader中的内容并保存，size不小于0时内容长度不一致会返回错误
+// reader 在锁外读取，写入 reader 的协程可以同时调用客户端
+func (fake *FakeClient) UploadStream(fileName string, reader io.Reader, size int64, options *UploadOptions) error {
+	fake.lock.Lock()
+	err := fake.record("UploadStream", fileName)
+	fake.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	if size >= 0 && int64(len(content)) != size {
+		return errors.NewBadRequest(fmt.Sprintf("content length mismatch: expect %d, but got %d", size, len(content)))
+	}
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	fake.put(fake.BucketName, fileName, content, options)
+	return nil
+}
+
+// OpenObject 返回对象内容的副本
+func (fake *FakeClient) OpenObject(fileName string) (io.ReadCloser, error) {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	if err := fake.record("OpenObject", fileName); err != nil {
+		return nil, err
+	}
+	object, err := fake.object(fake.BucketName, fileName)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(object.content)), nil
+}
+
+// OpenObjectRange 返回对象指定范围的内容，offset 不能超过对象大小
+func (fake *FakeClient) OpenObjectRange(fileName string, offset, length int64) (io.ReadCloser, error) {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	if err := fake.record("OpenObjectRange", fileName); err != nil {
+		return nil, err
+	}
+	if err := checkRange(offset, length); err != nil {
+		return nil, err
+	}
+	object, err := fake.object(fake.BucketName, fileName)
+	if err != nil {
+		return nil, err
+	}
+	size := int64(len(object.content))
+	if offset >= size {
+		return nil, errors.NewBadRequest(fmt.Sprintf("invalid range: offset %d, size %d", offset, size))
+	}
+	end := size
+	if length > 0 && offset+length < size {
+		end = offset + length
+	}
+	return ioutil.NopCloser(bytes.NewReader(object.content[offset:end])), nil
+}
+
+// UploadFileMultipart 读取本地文件并保存，分片与断点参数会被忽略
+func (fake *FakeClient) UploadFileMultipart(fileName, localFile string, options *MultipartOptions) error {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	if err := fake.record("UploadFileMultipart", fileName); err != nil {
+		return err
+	}
+	content, err := ioutil.ReadFile(localFile)
+	if err != nil {
+		return err
+	}
+	multipart := options.withDefaults(localFile)
+	fake.put(fake.BucketName, fileName, content, &multipart.UploadOptions)
+	return nil
+}
+
+// List 列举当前桶中的对象，注入的错误由迭代器的 Err 返回
+func (fake *FakeClient) List(prefix, delimiter string) ObjectIterator {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	if err := fake.record("List", prefix); err != nil {
+		return newErrorIterator(err)
+	}
+	objects := make([]ObjectInfo, 0, len(fake.buckets[fake.BucketName]))
+	for key, object := range fake.buckets[fake.BucketName] {
+		objects = append(objects, ObjectInfo{Key: key, Size: int64(len(object.content)), ETag: object.eTag,
+			LastModified: object.lastModified})
+	}
+	objects = filterObjects(objects, prefix, delimiter)
+	return newObjectIterator(func(string) ([]ObjectInfo, string, bool, error) {
+		return objects, "", false, nil
+	})
+}
+
+// Stat 返回对象的元数据
+func (fake *FakeClient) Stat(fileName string) (*ObjectStat, error) {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	if err := fake.record("Stat", fileName); err != nil {
+		return nil, err
+	}
+	object, err := fake.object(fake.BucketName, fileName)
+	if err != nil {
+		return nil, err
+	}
+	metadata := map[string]string{}
+	for key, value := range object.options.Metadata {
+		metadata[key] = value
+	}
+	return &ObjectStat{Key: fileName, Size: int64(len(object.content)), ETag: object.eTag,
+		ContentType: object.options.ContentType, LastModified: object.lastModified, Metadata: metadata}, nil
+}
+
+// Exists 判断对象是否存在
+func (fake *FakeClient) Exists(fileName string) (bool, error) {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	if err := fake.record("Exists", fileName); err != nil {
+		return false, err
+	}
+	_, ok := fake.buckets[fake.BucketName][fileName]
+	return ok, nil
+}
+
+// Copy 复制对象，DestBucket 不为空时复制到内存中的另一个桶
+func (fake *FakeClient) Copy(srcName, destName string, options *CopyOptions) error {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	if err := fake.record("Copy", srcName, destName); err != nil {
+		return err
+	}
+	return fake.copy(srcName, destName, options)
+}
+
+// Move 复制对象后删除源对象
+func (fake *FakeClient) Move(srcName, destName string, options *CopyOptions) error {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	if err := fake.record("Move", srcName, destName); err != nil {
+		return err
+	}
+	if err := fake.copy(srcName, destName, options); err != nil {
+		return err
+	}
+	if srcName != destName || options.destBucket(fake.BucketName) != fake.BucketName {
+		delete(fake.buckets[fake.BucketName], srcName)
+	}
+	return nil
+}
+
+// record 记录调用并返回注入的错误，调用方需要持有锁
+func (fake *FakeClient) record(method string, keys ...string) error {
+	fake.calls = append(fake.calls, FakeCall{Method: method, Keys: append([]string(nil), keys...)})
+	for _, name := range []string{method, ""} {
+		for _, key := range keys {
+			if err, ok := fake.errors[name+"\n"+key]; ok {
+				return err
+			}
+		}
+		if err, ok := fake.errors[name+"\n"]; ok {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fake *FakeClient) object(bucketName, fileName string) (*fakeObject, error) {
+	object, ok := fake.buckets[bucketName][fileName]
+	if !ok {
+		return nil, newNotFound(fileName)
+	}
+	return object, nil
+}
+
+func (fake *FakeClient) put(bucketName, fileName string, content []byte, options *UploadOptions) {
+	object := &fakeObject{content: append([]byte(nil), content...), lastModified: time.Now()}
+	if options != nil {
+		object.options = *options
+		object.options.Metadata = map[string]string{}
+		//与oss、obs一致，用户元数据的键为小写
+		for key, value := range options.Metadata {
+			object.options.Metadata[strings.ToLower(key)] = value
+		}
+	}
+	if object.options.ContentType == "" {
+		object.options.ContentType = contentTypeByName(fileName)
+	}
+	sum := md5.Sum(content)
+	object.eTag = hex.EncodeToString(sum[:])
+	if fake.buckets[bucketName] == nil {
+		fake.buckets[bucketName] = map[string]*fakeObject{}
+	}
+	fake.buckets[bucketName][fileName] = object
+}
+
+func (fake *FakeClient) copy(srcName, destName string, options *CopyOptions) error {
+	object, err := fake.object(fake.BucketName, srcName)
+	if err != nil {
+		return err
+	}
+	uploadOptions := object.options
+	if metadata := options.replaceMetadata(); metadata != nil {
+		uploadOptions = *metadata
+	}
+	fake.put(options.destBucket(fake.BucketName), destName, object.content, &uploadOptions)
+	return nil
+}
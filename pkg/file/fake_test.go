@@ -0,0 +1,159 @@
+package file
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFakeClient(t *testing.T) {
+	client := NewFakeClient("bucket")
+	if err := client.UploadStream("docs/a.txt", strings.NewReader("0123456789"), 10,
+		&UploadOptions{Metadata: map[string]string{"Owner": "ops"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.UploadStream("docs/b.txt", strings.NewReader("abc"), 5, nil); err == nil {
+		t.Fatal("expect error with content length mismatch")
+	}
+	if content := readObject(t, client, "docs/a.txt", 2, 3); content != "234" {
+		t.Fatalf("expect 234, but got %s", content)
+	}
+	if content := readObject(t, client, "docs/a.txt", 8, 100); content != "89" {
+		t.Fatalf("expect 89, but got %s", content)
+	}
+
+	stat, err := client.Stat("docs/a.txt")
+	if err != nil || stat.Size != 10 || stat.ETag != "781e5e245d69b566979b86e28d23f2c7" ||
+		stat.ContentType != "text/plain; charset=utf-8" || stat.Metadata["owner"] != "ops" {
+		t.Fatalf("unexpected stat: %+v, %v", stat, err)
+	}
+	if _, err = client.OpenObject("missing.txt"); !IsNotFound(err) {
+		t.Fatalf("expect not found, but got %v", err)
+	}
+
+	//写入 reader 的协程同时调用客户端时不能死锁
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		exists, err := client.Exists("docs/a.txt")
+		if err == nil && exists {
+			_, err = pipeWriter.Write([]byte("piped"))
+		}
+		pipeWriter.CloseWithError(err)
+	}()
+	if err = client.UploadStream("docs/piped.txt", pipeReader, -1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if content := readObject(t, client, "docs/piped.txt", 0, -1); content != "piped" {
+		t.Fatalf("expect piped, but got %s", content)
+	}
+
+	localFile := filepath.Join(t.TempDir(), "a.txt")
+	if _, err = client.DownloadFile("docs/a.txt", localFile); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.UploadFileMultipart("release/v1/a.txt", localFile, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.Copy("docs/a.txt", "release/v2/a.txt", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.Move("release/v2/a.txt", "release/a.txt", nil); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	iterator := client.List("release/", "/")
+	for iterator.Next() {
+		keys = append(keys, iterator.Object().Key)
+	}
+	if strings.Join(keys, ",") != "release/a.txt,release/v1/" {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+
+	if err = client.DeleteFiles([]string{"release/a.txt", "missing.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := client.Exists("release/a.txt"); err != nil || exists {
+		t.Fatalf("expect release/a.txt deleted, but got %v, %v", exists, err)
+	}
+	signedUrl, err := client.CreateSignedUrl("docs/a.txt", 60)
+	if err != nil || !strings.HasPrefix(signedUrl, "https://bucket.fake.local/docs/a.txt?Expires=") {
+		t.Fatalf("unexpected signed url: %s, %v", signedUrl, err)
+	}
+}
+
+func TestFakeClientCalls(t *testing.T) {
+	client := NewFakeClient("bucket")
+	var _ Client = client
+	_ = client.UploadFile("a.txt", []byte("a"))
+	_ = client.Copy("a.txt", "b.txt", &CopyOptions{DestBucket: "backup"})
+	client.Close()
+	calls := client.Calls()
+	if len(calls) != 3 || calls[0].Method != "UploadFile" || calls[1].Method != "Copy" ||
+		strings.Join(calls[1].Keys, ",") != "a.txt,b.txt" || calls[2].Method != "Close" {
+		t.Fatalf("unexpected calls: %+v", calls)
+	}
+	if exists, _ := client.Exists("b.txt"); exists {
+		t.Fatal("expect b.txt copied to another bucket")
+	}
+	backup := client.Bucket("backup")
+	if exists, err := backup.Exists("b.txt"); err != nil || !exists {
+		t.Fatalf("expect b.txt in backup bucket, but got %v, %v", exists, err)
+	}
+	if err := backup.UploadFile("c.txt", []byte("c")); err != nil {
+		t.Fatal(err)
+	}
+	if err := backup.Move("c.txt", "c.txt", &CopyOptions{DestBucket: "bucket"}); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := client.Exists("c.txt"); !exists {
+		t.Fatal("expect c.txt moved to bucket")
+	}
+	client.ResetCalls()
+	if len(client.Calls()) != 0 {
+		t.Fatal("expect calls reset")
+	}
+}
+
+func TestFakeClientErrors(t *testing.T) {
+	client := NewFakeClient("bucket")
+	_ = client.UploadFile("a.txt", []byte("a"))
+	timeout := errors.New("timeout")
+
+	client.SetError("Stat", "a.txt", timeout)
+	if _, err := client.Stat("a.txt"); err != timeout {
+		t.Fatalf("expect timeout, but got %v", err)
+	}
+	if _, err := client.OpenObject("a.txt"); err != nil {
+		t.Fatalf("expect no error for other method, but got %v", err)
+	}
+
+	client.SetError("", "b.txt", timeout)
+	if err := client.Copy("a.txt", "b.txt", nil); err != timeout {
+		t.Fatalf("expect timeout, but got %v", err)
+	}
+	if err := client.UploadFile("b.txt", nil); err != timeout {
+		t.Fatalf("expect timeout, but got %v", err)
+	}
+
+	client.SetError("List", "", timeout)
+	iterator := client.List("", "")
+	if iterator.Next() || iterator.Err() != timeout {
+		t.Fatalf("expect timeout, but got %v", iterator.Err())
+	}
+
+	client.SetError("Stat", "a.txt", nil)
+	client.SetError("", "b.txt", nil)
+	if _, err := client.Stat("a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.UploadFile("b.txt", []byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	body, _ := client.OpenObject("b.txt")
+	if content, _ := ioutil.ReadAll(body); string(content) != "b" {
+		t.Fatalf("expect b, but got %s", content)
+	}
+}
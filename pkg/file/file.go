@@ -1,20 +1,25 @@
 package file
 
 import (
+	"fmt"
+	"io"
+	"time"
+
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 // CloudVendors 云厂商基本参数
 type CloudVendors struct {
 	// ServerType 参数描述：服务商类型
 	ServerType string
-	// BucketName 参数描述：桶名
+	// BucketName 参数描述：桶名，ServerTypeLocal 时为根目录
 	BucketName string
-	// Endpoint 参数描述：节点
+	// Endpoint 参数描述：节点，ServerTypeLocal 时为签名url的地址前缀
 	Endpoint string
 	// Ak 参数描述：云服务器的ak
 	Ak string
-	// Sk 参数描述：云服务器的sk
+	// Sk 参数描述：云服务器的sk，ServerTypeLocal 时为签名url的密钥
 	Sk string
 }
 
@@ -25,11 +30,85 @@ type Client interface {
 	DeleteFiles(fileNames []string) error
 	Close()
 	UploadFile(fileName string, content []byte) error
+	UploadStream(fileName string, reader io.Reader, size int64, options *UploadOptions) error
+	OpenObject(fileName string) (io.ReadCloser, error)
+	OpenObjectRange(fileName string, offset, length int64) (io.ReadCloser, error)
+	UploadFileMultipart(fileName, localFile string, options *MultipartOptions) error
+	List(prefix, delimiter string) ObjectIterator
+	Stat(fileName string) (*ObjectStat, error)
+	Exists(fileName string) (bool, error)
+	Copy(srcName, destName string, options *CopyOptions) error
+	Move(srcName, destName string, options *CopyOptions) error
+}
+
+// CopyOptions 服务端复制选项，为nil时复制到当前桶并保留源文件的元数据
+type CopyOptions struct {
+	// DestBucket 参数描述：目标桶，为空时为当前桶，需要与当前桶属于同一区域
+	DestBucket string
+	// Metadata 参数描述：不为nil时使用其中的文件类型、http头与用户元数据替换源文件的元数据
+	Metadata *UploadOptions
+}
+
+// ObjectStat 对象的元数据
+type ObjectStat struct {
+	// Key 参数描述：对象名称
+	Key string
+	// Size 参数描述：对象大小
+	Size int64
+	// ETag 参数描述：对象的ETag，不包含引号
+	ETag string
+	// ContentType 参数描述：文件类型
+	ContentType string
+	// LastModified 参数描述：最后修改时间
+	LastModified time.Time
+	// Metadata 参数描述：用户自定义元数据，键为小写
+	Metadata map[string]string
+}
+
+// UploadOptions 上传选项，为nil或字段为空时不设置
+type UploadOptions struct {
+	// ContentType 参数描述：文件类型，为空时由服务商按文件后缀判断
+	ContentType string
+	// ContentDisposition 参数描述：下载时的展示方式，例如 attachment; filename="report.pdf"
+	ContentDisposition string
+	// CacheControl 参数描述：缓存策略，例如 no-cache、max-age=3600
+	CacheControl string
+	// Expires 参数描述：缓存过期时间
+	Expires time.Time
+	// Metadata 参数描述：用户自定义元数据
+	Metadata map[string]string
+}
+
+// MultipartOptions 分片上传选项，为nil或字段为零值时使用默认值
+type MultipartOptions struct {
+	UploadOptions
+	// PartSize 参数描述：分片大小，默认 DefaultPartSize，范围 100KB~5GB
+	PartSize int64
+	// Concurrency 参数描述：并发上传的分片数，默认 DefaultConcurrency
+	Concurrency int
+	// CheckpointFile 参数描述：断点记录文件，默认为 本地文件路径+CheckpointSuffix
+	// 上传中断后使用相同参数再次上传时，从断点记录继续上传未完成的分片，上传成功后删除
+	CheckpointFile string
 }
 
 const (
 	ServerTypeAliyun    = "aliyun"
 	ServerTypeHuaweiyun = "huaweiyun"
+	// ServerTypeLocal 本地文件系统，用于私有化部署与本地开发
+	ServerTypeLocal = "local"
+)
+
+const (
+	// DefaultPartSize 默认分片大小
+	DefaultPartSize = 9 * 1024 * 1024
+	// DefaultConcurrency 默认并发分片数
+	DefaultConcurrency = 5
+	// CheckpointSuffix 默认断点记录文件的后缀
+	CheckpointSuffix = ".upload.cp"
+	// CopyPartThreshold 超过该大小的文件使用分片复制
+	CopyPartThreshold = 1024 * 1024 * 1024
+	// CopyPartSize 分片复制的分片大小
+	CopyPartSize = 100 * 1024 * 1024
 )
 
 // InitCloudClient 初始化服务商客户端
@@ -39,8 +118,72 @@ func InitCloudClient(cloudVendors *CloudVendors) (client Client, err error) {
 		client, err = newOssClient(cloudVendors)
 	case ServerTypeHuaweiyun:
 		client, err = newObsClient(cloudVendors)
+	case ServerTypeLocal:
+		client, err = newLocalClient(cloudVendors)
 	default:
 		return nil, errors.NewBadRequest("unsupported client")
 	}
 	return
 }
+
+// IsNotFound 判断错误是否为文件不存在，Stat、OpenObject、DownloadFile 等方法在文件不存在时返回该错误
+func IsNotFound(err error) bool {
+	return errors.IsNotFound(err)
+}
+
+// newNotFound 文件不存在的错误
+func newNotFound(fileName string) error {
+	return errors.NewNotFound(schema.GroupResource{Resource: "files"}, fileName)
+}
+
+// checkRange 校验读取范围，offset 从0开始，length 小于0表示读取到文件末尾
+func checkRange(offset, length int64) error {
+	if offset < 0 || length == 0 {
+		return errors.NewBadRequest(fmt.Sprintf("invalid range: offset %d, length %d", offset, length))
+	}
+	return nil
+}
+
+// limitReadCloser 限制读取长度，关闭时关闭原始的body
+type limitReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func newLimitReadCloser(body io.ReadCloser, length int64) io.ReadCloser {
+	return &limitReadCloser{Reader: io.LimitReader(body, length), Closer: body}
+}
+
+// withDefaults 填充分片上传的默认值
+func (options *MultipartOptions) withDefaults(localFile string) MultipartOptions {
+	result := MultipartOptions{}
+	if options != nil {
+		result = *options
+	}
+	if result.PartSize <= 0 {
+		result.PartSize = DefaultPartSize
+	}
+	if result.Concurrency <= 0 {
+		result.Concurrency = DefaultConcurrency
+	}
+	if result.CheckpointFile == "" {
+		result.CheckpointFile = localFile + CheckpointSuffix
+	}
+	return result
+}
+
+// destBucket 复制的目标桶
+func (options *CopyOptions) destBucket(bucketName string) string {
+	if options == nil || options.DestBucket == "" {
+		return bucketName
+	}
+	return options.DestBucket
+}
+
+// replaceMetadata 需要替换的元数据，为nil时保留源文件的元数据
+func (options *CopyOptions) replaceMetadata() *UploadOptions {
+	if options == nil {
+		return nil
+	}
+	return options.Metadata
+}
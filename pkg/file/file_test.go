@@ -1,8 +1,13 @@
 package file
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"testing"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
 )
 
 func TestInitClient(t *testing.T) {
@@ -12,3 +17,43 @@ func TestInitClient(t *testing.T) {
 	}
 	fmt.Println("已初始化client---", client)
 }
+
+func TestMultipartOptionsDefaults(t *testing.T) {
+	options := (*MultipartOptions)(nil).withDefaults("/tmp/app.tar.gz")
+	if options.PartSize != DefaultPartSize || options.Concurrency != DefaultConcurrency ||
+		options.CheckpointFile != "/tmp/app.tar.gz"+CheckpointSuffix {
+		t.Fatalf("unexpected options: %+v", options)
+	}
+	options = (&MultipartOptions{PartSize: 1024 * 1024, CheckpointFile: "/tmp/cp"}).withDefaults("/tmp/app.tar.gz")
+	if options.PartSize != 1024*1024 || options.CheckpointFile != "/tmp/cp" {
+		t.Fatalf("unexpected options: %+v", options)
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	if !IsNotFound(ossError(oss.ServiceError{StatusCode: http.StatusNotFound}, "a.txt")) {
+		t.Fatal("expect oss 404 is not found")
+	}
+	obsError := obs.ObsError{}
+	obsError.StatusCode = http.StatusNotFound
+	if !IsNotFound(obsNotFound(obsError, "a.txt")) {
+		t.Fatal("expect obs 404 is not found")
+	}
+	if IsNotFound(ossError(oss.ServiceError{StatusCode: http.StatusForbidden}, "a.txt")) || IsNotFound(errors.New("404")) {
+		t.Fatal("expect other errors are not not found")
+	}
+}
+
+func TestCopyPartRanges(t *testing.T) {
+	cases := map[int64]string{
+		10: "[[0 3] [4 7] [8 9]]",
+		9:  "[[0 3] [4 8]]",
+		8:  "[[0 3] [4 7]]",
+		3:  "[[0 2]]",
+	}
+	for size, expected := range cases {
+		if ranges := fmt.Sprint(copyPartRanges(size, 4)); ranges != expected {
+			t.Fatalf("size %d: expect %s, but got %s", size, expected, ranges)
+		}
+	}
+}
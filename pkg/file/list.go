@@ -0,0 +1,134 @@
+package file
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// listPageSize 每次请求列举的最大数量，oss与obs均为1000
+const listPageSize = 1000
+
+// ObjectInfo 列举得到的对象信息
+type ObjectInfo struct {
+	// Key 参数描述：对象名称，IsPrefix 为true时为公共前缀，例如 release/
+	Key string
+	// Size 参数描述：对象大小
+	Size int64
+	// ETag 参数描述：对象的ETag，不包含引号
+	ETag string
+	// LastModified 参数描述：最后修改时间
+	LastModified time.Time
+	// IsPrefix 参数描述：是否为指定分隔符时折叠得到的公共前缀(目录)，公共前缀没有大小、ETag与修改时间
+	IsPrefix bool
+}
+
+// ObjectIterator 对象迭代器，按对象名称的字典序返回，自动翻页
+//
+//	iterator := client.List("release/", "/")
+//	for iterator.Next() {
+//		object := iterator.Object()
+//	}
+//	if err := iterator.Err(); err != nil {
+//	}
+type ObjectIterator interface {
+	// Next 移动到下一个对象，没有更多对象或出错时返回false
+	Next() bool
+	// Object 返回当前对象
+	Object() ObjectInfo
+	// Err 返回迭代过程中的错误
+	Err() error
+}
+
+// listPage 列举一页，返回的 nextMarker 为空时使用本页最后一个名称
+type listPage func(marker string) (objects []ObjectInfo, nextMarker string, truncated bool, err error)
+
+// objectIterator 按marker翻页的迭代器
+type objectIterator struct {
+	fetch   listPage
+	page    []ObjectInfo
+	index   int
+	marker  string
+	done    bool
+	current ObjectInfo
+	err     error
+}
+
+func newObjectIterator(fetch listPage) *objectIterator {
+	return &objectIterator{fetch: fetch}
+}
+
+// newErrorIterator 直接返回错误的迭代器
+func newErrorIterator(err error) *objectIterator {
+	return &objectIterator{done: true, err: err}
+}
+
+func (it *objectIterator) Next() bool {
+	for it.index >= len(it.page) {
+		if it.done || it.err != nil {
+			return false
+		}
+		page, nextMarker, truncated, err := it.fetch(it.marker)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		sort.Slice(page, func(i, j int) bool {
+			return page[i].Key < page[j].Key
+		})
+		if nextMarker == "" && len(page) > 0 {
+			nextMarker = page[len(page)-1].Key
+		}
+		//没有下一页的位置时停止，避免重复请求同一页
+		it.done = !truncated || nextMarker == "" || nextMarker == it.marker
+		it.page, it.index, it.marker = page, 0, nextMarker
+	}
+	it.current = it.page[it.index]
+	it.index++
+	return true
+}
+
+func (it *objectIterator) Object() ObjectInfo {
+	return it.current
+}
+
+func (it *objectIterator) Err() error {
+	return it.err
+}
+
+// listResult 将服务商的列举结果转换为 ObjectInfo
+func listResult(objects []ObjectInfo, commonPrefixes []string) []ObjectInfo {
+	for _, prefix := range commonPrefixes {
+		objects = append(objects, ObjectInfo{Key: prefix, IsPrefix: true})
+	}
+	return objects
+}
+
+// filterObjects 按前缀过滤对象，分隔符不为空时将前缀之后包含分隔符的对象折叠为公共前缀，用于不支持服务端列举的实现
+func filterObjects(objects []ObjectInfo, prefix, delimiter string) []ObjectInfo {
+	result := make([]ObjectInfo, 0)
+	prefixes := map[string]bool{}
+	commonPrefixes := make([]string, 0)
+	for _, object := range objects {
+		if !strings.HasPrefix(object.Key, prefix) {
+			continue
+		}
+		if delimiter != "" {
+			if index := strings.Index(object.Key[len(prefix):], delimiter); index >= 0 {
+				commonPrefix := object.Key[:len(prefix)+index+len(delimiter)]
+				if !prefixes[commonPrefix] {
+					prefixes[commonPrefix] = true
+					commonPrefixes = append(commonPrefixes, commonPrefix)
+				}
+				continue
+			}
+		}
+		result = append(result, object)
+	}
+	return listResult(result, commonPrefixes)
+}
+
+// trimETag 去掉ETag两端的引号
+func trimETag(eTag string) string {
+	return strings.Trim(eTag, "\"")
+}
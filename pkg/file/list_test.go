@@ -0,0 +1,46 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestObjectIterator(t *testing.T) {
+	var markers []string
+	iterator := newObjectIterator(func(marker string) ([]ObjectInfo, string, bool, error) {
+		markers = append(markers, marker)
+		switch marker {
+		case "":
+			return listResult([]ObjectInfo{{Key: "b"}, {Key: "a"}}, []string{"c/"}), "", true, nil
+		case "c/":
+			return []ObjectInfo{{Key: "d"}}, "", false, nil
+		}
+		return nil, "", false, fmt.Errorf("unexpected marker %s", marker)
+	})
+	var keys []string
+	for iterator.Next() {
+		keys = append(keys, iterator.Object().Key)
+	}
+	if err := iterator.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(keys) != "[a b c/ d]" || fmt.Sprint(markers) != "[ c/]" {
+		t.Fatalf("unexpected keys %v, markers %v", keys, markers)
+	}
+
+	failed := errors.New("list failed")
+	iterator = newObjectIterator(func(marker string) ([]ObjectInfo, string, bool, error) {
+		if marker == "" {
+			return []ObjectInfo{{Key: "a"}}, "a", true, nil
+		}
+		return nil, "", false, failed
+	})
+	count := 0
+	for iterator.Next() {
+		count++
+	}
+	if count != 1 || iterator.Err() != failed {
+		t.Fatalf("expect 1 object and error, but got %d, %v", count, iterator.Err())
+	}
+}
@@ -0,0 +1,725 @@
+package file
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// localMetadataDir 本地存储中保存元数据的目录，列举时会被忽略
+	localMetadataDir = ".metadata"
+	// localTempDir 元数据目录中保存上传中的临时文件的目录，与根目录在同一文件系统中，完成后重命名
+	localTempDir = "tmp"
+	// localSignatureParam 签名url中签名的参数名
+	localSignatureParam = "Signature"
+	// localExpiresParam 签名url中过期时间的参数名，值为unix时间戳
+	localExpiresParam = "Expires"
+)
+
+var _ Client = &localClientImpl{}
+
+// localClientImpl 本地文件系统客户端，桶对应一个目录，文件名中的 / 对应子目录
+type localClientImpl struct {
+	// Root 根目录，对应 CloudVendors.BucketName
+	Root string
+	// BaseUrl 签名url的地址前缀，对应 CloudVendors.Endpoint
+	BaseUrl string
+	// secret 签名密钥，对应 CloudVendors.Sk
+	secret []byte
+}
+
+// localMetadata 文件的元数据，保存路径见 metadataPath
+type localMetadata struct {
+	ETag               string            `json:"etag"`
+	ContentType        string            `json:"contentType,omitempty"`
+	ContentDisposition string            `json:"contentDisposition,omitempty"`
+	CacheControl       string            `json:"cacheControl,omitempty"`
+	Expires            *time.Time        `json:"expires,omitempty"`
+	Metadata           map[string]string `json:"metadata,omitempty"`
+}
+
+// newLocalClient
+/**
+ * 功能描述：初始化本地文件系统客户端
+ * @param cloudVendors BucketName 为根目录，不存在时创建；Endpoint 为签名url的地址前缀，例如 http://127.0.0.1:8080/files；Sk 为签名密钥
+ * @return *localClientImpl, error
+ */
+func newLocalClient(cloudVendors *CloudVendors) (*localClientImpl, error) {
+	if cloudVendors.BucketName == "" {
+		return &localClientImpl{}, errors.NewBadRequest("local root directory is not set")
+	}
+	root, err := filepath.Abs(cloudVendors.BucketName)
+	if err != nil {
+		return &localClientImpl{}, err
+	}
+	if err = os.MkdirAll(filepath.Join(root, localMetadataDir), 0755); err != nil {
+		klog.Error(err)
+		return &localClientImpl{}, err
+	}
+	return &localClientImpl{Root: root, BaseUrl: strings.TrimSuffix(cloudVendors.Endpoint, "/"),
+		secret: []byte(cloudVendors.Sk)}, nil
+}
+
+// Close 本地文件系统无需关闭
+func (localClient *localClientImpl) Close() {
+}
+
+// DownloadFile
+/**
+ * 功能描述：复制到本地文件
+ * @param fileName 文件名称
+ * @param localFile 本地存储路径
+ * @return string, error
+ */
+func (localClient *localClientImpl) DownloadFile(fileName, localFile string) (string, error) {
+	src, err := localClient.OpenObject(fileName)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	dest, err := os.Create(localFile)
+	if err != nil {
+		klog.Error(err)
+		return "", err
+	}
+	if _, err = io.Copy(dest, src); err != nil {
+		dest.Close()
+		klog.Error(err)
+		return "", err
+	}
+	return localFile, dest.Close()
+}
+
+// CreateSignedUrl
+/**
+ * 功能描述：创建带HMAC签名的url，由 NewLocalHandler 校验签名后提供下载
+ * @param fileName 文件名称
+ * @param expires 过期时间，单位秒
+ * @return string, error
+ */
+func (localClient *localClientImpl) CreateSignedUrl(fileName string, expires int) (string, error) {
+	if localClient.BaseUrl == "" || len(localClient.secret) == 0 {
+		return "", errors.NewBadRequest("local endpoint and sk are required to create signed url")
+	}
+	if _, err := localClient.path(localClient.Root, fileName); err != nil {
+		return "", err
+	}
+	expiresAt := strconv.FormatInt(time.Now().Add(time.Duration(expires)*time.Second).Unix(), 10)
+	query := url.Values{}
+	query.Set(localExpiresParam, expiresAt)
+	query.Set(localSignatureParam, localSignature(localClient.secret, fileName, expiresAt))
+	return localClient.BaseUrl + "/" + escapeKey(fileName) + "?" + query.Encode(), nil
+}
+
+// DeleteFiles
+/**
+ * 功能描述：批量删除文件，不存在的文件会被忽略
+ * @param fileNames 要删除的文件名称的数组
+ * @return error
+ */
+func (localClient *localClientImpl) DeleteFiles(fileNames []string) error {
+	deletes := make([]string, 0)
+	for _, fileName := range fileNames {
+		filePath, err := localClient.path(localClient.Root, fileName)
+		if err != nil {
+			return err
+		}
+		if err = os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+			klog.Error(err)
+			return err
+		}
+		err = os.Remove(localClient.metadataPath(localClient.Root, fileName))
+		if err != nil && !os.IsNotExist(err) {
+			klog.Error(err)
+			return err
+		}
+		deletes = append(deletes, fileName)
+	}
+	if len(deletes) > 0 {
+		klog.Info("已成功删除的文件：", deletes)
+	}
+	return nil
+}
+
+// UploadFile
+/**
+ * 功能描述：将指定的内容写入文件
+ * @param fileName 文件名称
+ * @param content 文件内容
+ * @return error
+ */
+func (localClient *localClientImpl) UploadFile(fileName string, content []byte) error {
+	return localClient.UploadStream(fileName, bytes.NewReader(content), int64(len(content)), nil)
+}
+
+// UploadStream
+/**
+ * 功能描述：将reader中的内容写入文件，先写入临时文件，完成后替换，读取失败时不会留下不完整的文件
+ * @param fileName 文件名称
+ * @param reader 文件内容
+ * @param size 文件大小，小于0表示未知，不小于0时内容长度不一致会返回错误
+ * @param options 上传选项，可以为nil
+ * @return error
+ */
+func (localClient *localClientImpl) UploadStream(fileName string, reader io.Reader, size int64, options *UploadOptions) error {
+	return localClient.write(localClient.Root, fileName, reader, size, options)
+}
+
+// OpenObject
+/**
+ * 功能描述：打开文件，调用方读取完成后需要关闭
+ * @param fileName 文件名称
+ * @return io.ReadCloser, error
+ */
+func (localClient *localClientImpl) OpenObject(fileName string) (io.ReadCloser, error) {
+	filePath, err := localClient.path(localClient.Root, fileName)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, localError(err, fileName)
+	}
+	//目录不是文件，与 Stat 一致返回文件不存在
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, localError(err, fileName)
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, newNotFound(fileName)
+	}
+	return file, nil
+}
+
+// OpenObjectRange
+/**
+ * 功能描述：读取文件的指定范围，调用方读取完成后需要关闭
+ * @param fileName 文件名称
+ * @param offset 起始位置，从0开始，不能超过文件大小
+ * @param length 读取长度，小于0表示读取到文件末尾
+ * @return io.ReadCloser, error
+ */
+func (localClient *localClientImpl) OpenObjectRange(fileName string, offset, length int64) (io.ReadCloser, error) {
+	if err := checkRange(offset, length); err != nil {
+		return nil, err
+	}
+	body, err := localClient.OpenObject(fileName)
+	if err != nil {
+		return nil, err
+	}
+	file := body.(*os.File)
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if offset >= info.Size() {
+		file.Close()
+		return nil, errors.NewBadRequest(fmt.Sprintf("invalid range: offset %d, size %d", offset, info.Size()))
+	}
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if length < 0 {
+		return file, nil
+	}
+	return newLimitReadCloser(file, length), nil
+}
+
+// UploadFileMultipart
+/**
+ * 功能描述：复制本地文件，本地文件系统无需分片与断点续传，分片与断点参数会被忽略
+ * @param fileName 文件名称
+ * @param localFile 本地文件路径
+ * @param options 分片上传选项，可以为nil
+ * @return error
+ */
+func (localClient *localClientImpl) UploadFileMultipart(fileName, localFile string, options *MultipartOptions) error {
+	src, err := os.Open(localFile)
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	defer src.Close()
+	multipart := options.withDefaults(localFile)
+	return localClient.UploadStream(fileName, src, -1, &multipart.UploadOptions)
+}
+
+// List
+/**
+ * 功能描述：列举目录中的文件，一次列举所有文件
+ * @param prefix 文件名前缀，为空时列举所有文件，前缀中的目录部分按文件名校验，不能包含 . 与 .. 路径
+ * @param delimiter 分隔符，例如 /，不为空时按分隔符折叠为公共前缀
+ * @return ObjectIterator
+ */
+func (localClient *localClientImpl) List(prefix, delimiter string) ObjectIterator {
+	return newObjectIterator(func(string) ([]ObjectInfo, string, bool, error) {
+		objects, err := localClient.list(prefix, delimiter)
+		return objects, "", false, err
+	})
+}
+
+func (localClient *localClientImpl) list(prefix, delimiter string) ([]ObjectInfo, error) {
+	// 只遍历前缀所在的目录，目录按文件名校验，不能遍历根目录以外的目录
+	dir := localClient.Root
+	if index := strings.LastIndex(prefix, "/"); index >= 0 {
+		var err error
+		if dir, err = localClient.path(localClient.Root, prefix[:index]); err != nil {
+			return nil, err
+		}
+	}
+	objects := make([]ObjectInfo, 0)
+	err := filepath.Walk(dir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(localClient.Root, filePath)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if info.IsDir() {
+			if key == localMetadataDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		object := ObjectInfo{Key: key, Size: info.Size(), LastModified: info.ModTime()}
+		if metadata, err := localClient.readMetadata(localClient.Root, key); err == nil {
+			object.ETag = metadata.ETag
+		}
+		objects = append(objects, object)
+		return nil
+	})
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	return filterObjects(objects, prefix, delimiter), nil
+}
+
+// Stat
+/**
+ * 功能描述：获取文件的元数据，文件不存在时返回的错误可以使用 IsNotFound 判断
+ * 不是通过客户端写入的文件没有ETag，文件类型按后缀判断
+ * @param fileName 文件名称
+ * @return *ObjectStat, error
+ */
+func (localClient *localClientImpl) Stat(fileName string) (*ObjectStat, error) {
+	filePath, err := localClient.path(localClient.Root, fileName)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, localError(err, fileName)
+	}
+	if info.IsDir() {
+		return nil, newNotFound(fileName)
+	}
+	metadata, err := localClient.readMetadata(localClient.Root, fileName)
+	if err != nil {
+		return nil, err
+	}
+	userMetadata := map[string]string{}
+	for key, value := range metadata.Metadata {
+		userMetadata[strings.ToLower(key)] = value
+	}
+	return &ObjectStat{Key: fileName, Size: info.Size(), ETag: metadata.ETag, ContentType: metadata.ContentType,
+		LastModified: info.ModTime(), Metadata: userMetadata}, nil
+}
+
+// Exists
+/**
+ * 功能描述：判断文件是否存在
+ * @param fileName 文件名称
+ * @return bool, error
+ */
+func (localClient *localClientImpl) Exists(fileName string) (bool, error) {
+	_, err := localClient.Stat(fileName)
+	if IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Copy
+/**
+ * 功能描述：复制文件，DestBucket 为目标根目录
+ * @param srcName 源文件名称
+ * @param destName 目标文件名称
+ * @param options 复制选项，可以为nil
+ * @return error
+ */
+func (localClient *localClientImpl) Copy(srcName, destName string, options *CopyOptions) error {
+	metadata, err := localClient.copyMetadata(srcName, options)
+	if err != nil {
+		return err
+	}
+	src, err := localClient.OpenObject(srcName)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return localClient.write(options.destBucket(localClient.Root), destName, src, -1, metadata)
+}
+
+// Move
+/**
+ * 功能描述：移动文件，目标为同一根目录且不替换元数据时直接重命名
+ * @param srcName 源文件名称
+ * @param destName 目标文件名称
+ * @param options 复制选项，可以为nil
+ * @return error
+ */
+func (localClient *localClientImpl) Move(srcName, destName string, options *CopyOptions) error {
+	destRoot := options.destBucket(localClient.Root)
+	if destRoot != localClient.Root || options.replaceMetadata() != nil {
+		if err := localClient.Copy(srcName, destName, options); err != nil {
+			return err
+		}
+		if srcName == destName && destRoot == localClient.Root {
+			return nil
+		}
+		return localClient.DeleteFiles([]string{srcName})
+	}
+	srcPath, err := localClient.path(localClient.Root, srcName)
+	if err != nil {
+		return err
+	}
+	destPath, err := localClient.path(localClient.Root, destName)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return localError(err, srcName)
+	}
+	if info.IsDir() {
+		return newNotFound(srcName)
+	}
+	destMetadataPath := localClient.metadataPath(localClient.Root, destName)
+	for _, dir := range []string{filepath.Dir(destPath), filepath.Dir(destMetadataPath)} {
+		if err = os.MkdirAll(dir, 0755); err != nil {
+			klog.Error(err)
+			return err
+		}
+	}
+	if err = os.Rename(srcPath, destPath); err != nil {
+		klog.Error(err)
+		return err
+	}
+	err = os.Rename(localClient.metadataPath(localClient.Root, srcName), destMetadataPath)
+	if os.IsNotExist(err) {
+		//源文件没有元数据时删除目标文件原有的元数据
+		if err = os.Remove(destMetadataPath); os.IsNotExist(err) {
+			err = nil
+		}
+	}
+	if err != nil {
+		//元数据移动失败时还原文件，保持文件与元数据一致
+		klog.Error(err)
+		if rollbackErr := os.Rename(destPath, srcPath); rollbackErr != nil {
+			klog.Error(rollbackErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// copyMetadata 复制时目标文件的元数据，未指定替换时使用源文件的元数据
+func (localClient *localClientImpl) copyMetadata(srcName string, options *CopyOptions) (*UploadOptions, error) {
+	if metadata := options.replaceMetadata(); metadata != nil {
+		return metadata, nil
+	}
+	metadata, err := localClient.readMetadata(localClient.Root, srcName)
+	if err != nil {
+		return nil, err
+	}
+	uploadOptions := &UploadOptions{ContentType: metadata.ContentType, ContentDisposition: metadata.ContentDisposition,
+		CacheControl: metadata.CacheControl, Metadata: metadata.Metadata}
+	if metadata.Expires != nil {
+		uploadOptions.Expires = *metadata.Expires
+	}
+	return uploadOptions, nil
+}
+
+// write 写入文件与元数据
+func (localClient *localClientImpl) write(root, fileName string, reader io.Reader, size int64, options *UploadOptions) error {
+	filePath, err := localClient.path(root, fileName)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		klog.Error(err)
+		return err
+	}
+	temp, err := localClient.tempFile(root)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	hash := md5.New()
+	written, err := io.Copy(io.MultiWriter(temp, hash), reader)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && size >= 0 && written != size {
+		err = errors.NewBadRequest(fmt.Sprintf("content length mismatch: expect %d, but got %d", size, written))
+	}
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+
+	metadata := &localMetadata{ETag: hex.EncodeToString(hash.Sum(nil))}
+	if options != nil {
+		metadata.ContentType = options.ContentType
+		metadata.ContentDisposition = options.ContentDisposition
+		metadata.CacheControl = options.CacheControl
+		metadata.Metadata = options.Metadata
+		if !options.Expires.IsZero() {
+			expires := options.Expires
+			metadata.Expires = &expires
+		}
+	}
+	if metadata.ContentType == "" {
+		metadata.ContentType = contentTypeByName(fileName)
+	}
+	//先删除旧的元数据再替换文件，替换后再写入元数据，任何一步失败都不会留下描述其他内容的元数据
+	metadataPath := localClient.metadataPath(root, fileName)
+	if err = os.Remove(metadataPath); err != nil && !os.IsNotExist(err) {
+		klog.Error(err)
+		return err
+	}
+	if err = os.Rename(temp.Name(), filePath); err != nil {
+		klog.Error(err)
+		return err
+	}
+	return localClient.writeMetadata(root, fileName, metadata)
+}
+
+// readMetadata 读取元数据，元数据不存在时按文件后缀判断文件类型
+func (localClient *localClientImpl) readMetadata(root, fileName string) (*localMetadata, error) {
+	data, err := ioutil.ReadFile(localClient.metadataPath(root, fileName))
+	if os.IsNotExist(err) {
+		return &localMetadata{ContentType: contentTypeByName(fileName)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	metadata := &localMetadata{}
+	if err = json.Unmarshal(data, metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+func (localClient *localClientImpl) writeMetadata(root, fileName string, metadata *localMetadata) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	metadataPath := localClient.metadataPath(root, fileName)
+	if err = os.MkdirAll(filepath.Dir(metadataPath), 0755); err != nil {
+		klog.Error(err)
+		return err
+	}
+	//写入临时文件后重命名，读取时不会得到不完整的元数据
+	temp, err := localClient.tempFile(root)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), metadataPath)
+	}
+	if err != nil {
+		klog.Error(err)
+	}
+	return err
+}
+
+// tempFile 在元数据目录中创建临时文件，列举时不会返回上传中的文件
+func (localClient *localClientImpl) tempFile(root string) (*os.File, error) {
+	dir := filepath.Join(root, localMetadataDir, localTempDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	temp, err := ioutil.TempFile(dir, "upload-*")
+	if err != nil {
+		klog.Error(err)
+	}
+	return temp, err
+}
+
+// path 校验文件名并返回文件路径，文件名不能为空，不能以 / 开头或结尾，不能包含 . 与 .. 路径
+func (localClient *localClientImpl) path(root, fileName string) (string, error) {
+	if fileName == "" || path.Clean("/" + fileName)[1:] != fileName ||
+		fileName == localMetadataDir || strings.HasPrefix(fileName, localMetadataDir+"/") {
+		return "", errors.NewBadRequest(fmt.Sprintf("invalid file name: %q", fileName))
+	}
+	return filepath.Join(root, filepath.FromSlash(fileName)), nil
+}
+
+// metadataPath 元数据路径为 Root/.metadata/<sha256(文件名)前两位>/<sha256(文件名)>.json
+// 使用文件名的摘要，避免 x 与 x.json/y 这类文件名的元数据路径互相冲突
+func (localClient *localClientImpl) metadataPath(root, fileName string) string {
+	sum := sha256.Sum256([]byte(fileName))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(root, localMetadataDir, name[:2], name+".json")
+}
+
+// localError 将文件不存在转换为 IsNotFound 可以判断的错误
+func localError(err error, fileName string) error {
+	if os.IsNotExist(err) {
+		return newNotFound(fileName)
+	}
+	klog.Error(err)
+	return err
+}
+
+func contentTypeByName(fileName string) string {
+	contentType := mime.TypeByExtension(path.Ext(fileName))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return contentType
+}
+
+// localSignature 签名内容为 文件名 + 换行 + 过期时间
+func localSignature(secret []byte, fileName, expires string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(fileName + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// escapeKey 按路径分段转义文件名
+func escapeKey(fileName string) string {
+	segments := strings.Split(fileName, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// localHandler 校验签名并提供文件下载
+type localHandler struct {
+	client *localClientImpl
+	// prefix Endpoint 中的路径，请求路径去掉该前缀后为文件名
+	prefix string
+}
+
+// NewLocalHandler
+/**
+ * 功能描述：创建本地文件的下载服务，校验 CreateSignedUrl 生成的签名与过期时间，支持GET、HEAD与Range请求
+ * 需要挂载在 Endpoint 的路径下，例如 Endpoint 为 http://127.0.0.1:8080/files 时：http.Handle("/files/", handler)
+ * @param cloudVendors 与 InitCloudClient 使用相同的参数
+ * @return http.Handler, error
+ */
+func NewLocalHandler(cloudVendors *CloudVendors) (http.Handler, error) {
+	client, err := newLocalClient(cloudVendors)
+	if err != nil {
+		return nil, err
+	}
+	if len(client.secret) == 0 {
+		return nil, errors.NewBadRequest("local sk is required to verify signed url")
+	}
+	endpoint, err := url.Parse(client.BaseUrl)
+	if err != nil {
+		return nil, err
+	}
+	return &localHandler{client: client, prefix: strings.TrimSuffix(endpoint.Path, "/") + "/"}, nil
+}
+
+func (h *localHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, h.prefix) {
+		http.NotFound(w, r)
+		return
+	}
+	fileName := strings.TrimPrefix(r.URL.Path, h.prefix)
+	query := r.URL.Query()
+	expires := query.Get(localExpiresParam)
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt ||
+		!hmac.Equal([]byte(query.Get(localSignatureParam)), []byte(localSignature(h.client.secret, fileName, expires))) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	body, err := h.client.OpenObject(fileName)
+	if err != nil {
+		if IsNotFound(err) || errors.IsBadRequest(err) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer body.Close()
+	file := body.(*os.File)
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	metadata, err := h.client.readMetadata(h.client.Root, fileName)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	header := w.Header()
+	header.Set("Content-Type", metadata.ContentType)
+	if metadata.ETag != "" {
+		header.Set("ETag", "\""+metadata.ETag+"\"")
+	}
+	if metadata.ContentDisposition != "" {
+		header.Set("Content-Disposition", metadata.ContentDisposition)
+	}
+	if metadata.CacheControl != "" {
+		header.Set("Cache-Control", metadata.CacheControl)
+	}
+	if metadata.Expires != nil {
+		header.Set("Expires", metadata.Expires.UTC().Format(http.TimeFormat))
+	}
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
@@ -0,0 +1,319 @@
+package file
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+func newTestLocalClient(t *testing.T) (Client, *CloudVendors) {
+	t.Helper()
+	cloudVendors := &CloudVendors{ServerType: ServerTypeLocal, BucketName: t.TempDir(),
+		Endpoint: "http://127.0.0.1/files", Sk: "secret"}
+	client, err := InitCloudClient(cloudVendors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, cloudVendors
+}
+
+func readObject(t *testing.T, client Client, fileName string, offset, length int64) string {
+	t.Helper()
+	body, err := client.OpenObjectRange(fileName, offset, length)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestLocalUploadAndOpen(t *testing.T) {
+	client, _ := newTestLocalClient(t)
+	err := client.UploadStream("docs/readme.txt", strings.NewReader("0123456789"), 10,
+		&UploadOptions{ContentDisposition: "attachment", Metadata: map[string]string{"Owner": "ops"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content := readObject(t, client, "docs/readme.txt", 2, 3); content != "234" {
+		t.Fatalf("expect 234, but got %s", content)
+	}
+	if content := readObject(t, client, "docs/readme.txt", 8, -1); content != "89" {
+		t.Fatalf("expect 89, but got %s", content)
+	}
+	if _, err = client.OpenObjectRange("docs/readme.txt", 10, -1); err == nil {
+		t.Fatal("expect error with offset out of range")
+	}
+
+	stat, err := client.Stat("docs/readme.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.Size != 10 || stat.ETag != "781e5e245d69b566979b86e28d23f2c7" ||
+		stat.ContentType != "text/plain; charset=utf-8" || stat.Metadata["owner"] != "ops" {
+		t.Fatalf("unexpected stat: %+v", stat)
+	}
+
+	if err = client.UploadStream("short.txt", strings.NewReader("abc"), 5, nil); err == nil {
+		t.Fatal("expect error with content length mismatch")
+	}
+	if exists, err := client.Exists("short.txt"); err != nil || exists {
+		t.Fatalf("expect short.txt not exists, but got %v, %v", exists, err)
+	}
+	for _, fileName := range []string{"", "/abs", "a/../b", "dir/", ".metadata/x"} {
+		if err = client.UploadFile(fileName, []byte("x")); err == nil {
+			t.Fatalf("expect error with file name %q", fileName)
+		}
+	}
+
+	//文件名互为前缀时元数据不能冲突
+	if err = client.UploadFile("x", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.UploadFile("x.json/y", []byte("y")); err != nil {
+		t.Fatal(err)
+	}
+	for _, fileName := range []string{"x", "x.json/y"} {
+		if stat, err := client.Stat(fileName); err != nil || stat.Size != 1 || stat.ETag == "" {
+			t.Fatalf("unexpected stat of %s: %+v, %v", fileName, stat, err)
+		}
+	}
+
+	localFile := filepath.Join(t.TempDir(), "readme.txt")
+	if _, err = client.DownloadFile("docs/readme.txt", localFile); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.DownloadFile("missing.txt", localFile); !IsNotFound(err) {
+		t.Fatalf("expect not found, but got %v", err)
+	}
+	//目录不是文件
+	if _, err = client.OpenObject("docs"); !IsNotFound(err) {
+		t.Fatalf("expect not found, but got %v", err)
+	}
+	if _, err = client.DownloadFile("docs", localFile); !IsNotFound(err) {
+		t.Fatalf("expect not found, but got %v", err)
+	}
+	if err = client.UploadFileMultipart("copy/readme.txt", localFile, nil); err != nil {
+		t.Fatal(err)
+	}
+	if content := readObject(t, client, "copy/readme.txt", 0, -1); content != "0123456789" {
+		t.Fatalf("expect 0123456789, but got %s", content)
+	}
+}
+
+// readFunc 将函数作为 io.Reader
+type readFunc func(p []byte) (int, error)
+
+func (f readFunc) Read(p []byte) (int, error) {
+	return f(p)
+}
+
+func TestLocalList(t *testing.T) {
+	client, _ := newTestLocalClient(t)
+	for _, fileName := range []string{"a.txt", "release/v1/app", "release/v2/app", "release/notes.md", "other/x"} {
+		if err := client.UploadFile(fileName, []byte(fileName)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cases := []struct {
+		prefix, delimiter string
+		expected          []string
+	}{
+		{"", "", []string{"a.txt", "other/x", "release/notes.md", "release/v1/app", "release/v2/app"}},
+		{"", "/", []string{"a.txt", "other/", "release/"}},
+		{"release/", "/", []string{"release/notes.md", "release/v1/", "release/v2/"}},
+		{"release/v", "", []string{"release/v1/app", "release/v2/app"}},
+		{"missing/", "/", nil},
+	}
+	for _, c := range cases {
+		var keys []string
+		iterator := client.List(c.prefix, c.delimiter)
+		for iterator.Next() {
+			keys = append(keys, iterator.Object().Key)
+		}
+		if err := iterator.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(keys, ",") != strings.Join(c.expected, ",") {
+			t.Fatalf("%q %q: expect %v, but got %v", c.prefix, c.delimiter, c.expected, keys)
+		}
+	}
+
+	//前缀不能指向根目录以外的目录
+	for _, prefix := range []string{"../", "release/../../", "/etc/", ".metadata/"} {
+		iterator := client.List(prefix, "")
+		if iterator.Next() || !errors.IsBadRequest(iterator.Err()) {
+			t.Fatalf("%q: expect bad request, but got %v", prefix, iterator.Err())
+		}
+	}
+
+	//上传中的临时文件不能被列举
+	var listed []string
+	reader := io.MultiReader(strings.NewReader("partial"), readFunc(func([]byte) (int, error) {
+		iterator := client.List("release/v1/", "")
+		for iterator.Next() {
+			listed = append(listed, iterator.Object().Key)
+		}
+		return 0, io.EOF
+	}))
+	if err := client.UploadStream("release/v1/uploading", reader, -1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(listed, ",") != "release/v1/app" {
+		t.Fatalf("expect release/v1/app, but got %v", listed)
+	}
+}
+
+func TestLocalCopyAndMove(t *testing.T) {
+	client, cloudVendors := newTestLocalClient(t)
+	if err := client.UploadStream("src.json", strings.NewReader("{}"), -1,
+		&UploadOptions{Metadata: map[string]string{"k": "v"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Copy("src.json", "dir/copy.json", nil); err != nil {
+		t.Fatal(err)
+	}
+	stat, err := client.Stat("dir/copy.json")
+	if err != nil || stat.Metadata["k"] != "v" || stat.ContentType != "application/json" {
+		t.Fatalf("unexpected stat: %+v, %v", stat, err)
+	}
+
+	if err = client.Move("dir/copy.json", "moved.json", nil); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := client.Exists("dir/copy.json"); exists {
+		t.Fatal("expect dir/copy.json moved")
+	}
+	if stat, err = client.Stat("moved.json"); err != nil || stat.Metadata["k"] != "v" {
+		t.Fatalf("unexpected stat: %+v, %v", stat, err)
+	}
+
+	err = client.Move("moved.json", "moved.json", &CopyOptions{Metadata: &UploadOptions{ContentType: "text/plain"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat, err = client.Stat("moved.json"); err != nil || stat.ContentType != "text/plain" || len(stat.Metadata) != 0 {
+		t.Fatalf("unexpected stat: %+v, %v", stat, err)
+	}
+
+	destRoot := t.TempDir()
+	if err = client.Copy("src.json", "src.json", &CopyOptions{DestBucket: destRoot}); err != nil {
+		t.Fatal(err)
+	}
+	dest, err := InitCloudClient(&CloudVendors{ServerType: ServerTypeLocal, BucketName: destRoot})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := dest.Exists("src.json"); err != nil || !exists {
+		t.Fatalf("expect src.json copied, but got %v, %v", exists, err)
+	}
+
+	//源文件没有元数据时，移动后不能保留目标文件原有的元数据
+	if err = ioutil.WriteFile(filepath.Join(cloudVendors.BucketName, "plain.txt"), []byte("plain"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.Move("plain.txt", "moved.json", nil); err != nil {
+		t.Fatal(err)
+	}
+	if stat, err = client.Stat("moved.json"); err != nil || stat.ETag != "" || stat.ContentType != "application/json" {
+		t.Fatalf("unexpected stat: %+v, %v", stat, err)
+	}
+
+	//目录不是文件，不能移动
+	if err = client.UploadFile("dir/a.txt", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.Move("dir", "moved", nil); !IsNotFound(err) {
+		t.Fatalf("expect not found, but got %v", err)
+	}
+	if stat, err = client.Stat("dir/a.txt"); err != nil || stat.ETag == "" {
+		t.Fatalf("unexpected stat: %+v, %v", stat, err)
+	}
+	if err = client.Move("missing.json", "x.json", nil); !IsNotFound(err) {
+		t.Fatalf("expect not found, but got %v", err)
+	}
+	if err = client.DeleteFiles([]string{"src.json", "missing.json"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.Stat("src.json"); !IsNotFound(err) {
+		t.Fatalf("expect not found, but got %v", err)
+	}
+}
+
+func TestLocalHandler(t *testing.T) {
+	client, cloudVendors := newTestLocalClient(t)
+	if err := client.UploadStream("dir/hello world.txt", strings.NewReader("hello world"), -1,
+		&UploadOptions{ContentDisposition: "attachment"}); err != nil {
+		t.Fatal(err)
+	}
+	handler, err := NewLocalHandler(cloudVendors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedUrl, err := client.CreateSignedUrl("dir/hello world.txt", 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(signedUrl, "http://127.0.0.1/files/dir/hello%20world.txt?") {
+		t.Fatalf("unexpected signed url: %s", signedUrl)
+	}
+
+	serve := func(rawUrl string, header http.Header) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, rawUrl, nil)
+		for key := range header {
+			request.Header.Set(key, header.Get(key))
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	response := serve(signedUrl, nil)
+	if response.Code != http.StatusOK || response.Body.String() != "hello world" ||
+		response.Header().Get("Content-Disposition") != "attachment" {
+		t.Fatalf("unexpected response: %d %s %v", response.Code, response.Body.String(), response.Header())
+	}
+	response = serve(signedUrl, http.Header{"Range": []string{"bytes=6-"}})
+	if response.Code != http.StatusPartialContent || response.Body.String() != "world" {
+		t.Fatalf("unexpected response: %d %s", response.Code, response.Body.String())
+	}
+
+	parsed, _ := url.Parse(signedUrl)
+	query := parsed.Query()
+	query.Set(localExpiresParam, "9999999999")
+	parsed.RawQuery = query.Encode()
+	if response = serve(parsed.String(), nil); response.Code != http.StatusForbidden {
+		t.Fatalf("expect 403 with tampered expires, but got %d", response.Code)
+	}
+	expired, err := client.CreateSignedUrl("dir/hello world.txt", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response = serve(expired, nil); response.Code != http.StatusForbidden {
+		t.Fatalf("expect 403 with expired url, but got %d", response.Code)
+	}
+	for _, fileName := range []string{"missing.txt", "dir"} {
+		missing, _ := client.CreateSignedUrl(fileName, 60)
+		if response = serve(missing, nil); response.Code != http.StatusNotFound {
+			t.Fatalf("%s: expect 404, but got %d", fileName, response.Code)
+		}
+	}
+
+	unsigned, err := InitCloudClient(&CloudVendors{ServerType: ServerTypeLocal, BucketName: cloudVendors.BucketName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = unsigned.CreateSignedUrl("dir/hello world.txt", 60); err == nil {
+		t.Fatal("expect error without endpoint and sk")
+	}
+}
@@ -1,8 +1,12 @@
 package file
 
 import (
-	"errors"
+	"io"
+	"math"
+	"net/http"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
 	"k8s.io/klog/v2"
@@ -63,12 +67,13 @@ func (obsClient *obsClientImpl) DownloadFile(fileName, localFile string) (string
 	input.TaskNum = 5
 	_, downErr := obsClient.ObsClient.DownloadFile(input)
 	if obsError, ok := downErr.(obs.ObsError); ok {
-		klog.Error("Code:%s\n", obsError.Code)
-		klog.Error("Message:%s\n", obsError.Message)
-		if obsError.Code == "404" {
-			return "", errors.New("404")
-		}
-		return "", obsError
+		klog.Errorf("Code:%s\n", obsError.Code)
+		klog.Errorf("Message:%s\n", obsError.Message)
+		return "", obsNotFound(obsError, fileName)
+	}
+	if downErr != nil {
+		klog.Error(downErr)
+		return "", downErr
 	}
 	return localFile, nil
 }
@@ -152,3 +157,393 @@ func (obsClient *obsClientImpl) UploadFile(fileName string, content []byte) erro
 	}
 	return err
 }
+
+// UploadStream https://support.huaweicloud.com/sdk-go-devg-obs/obs_23_0402.html#section5
+/**
+ * 功能描述：将reader中的内容流式上传至obs指定桶中，不会将内容全部读入内存
+ * obs上传接口不支持 Content-Disposition、Cache-Control 与 Expires，上传成功后通过设置对象元数据写入
+ * @param fileName 对应obs的文件名
+ * @param reader 文件内容
+ * @param size 文件大小，小于0表示未知
+ * @param options 上传选项，可以为nil
+ * @return error
+ */
+func (obsClient *obsClientImpl) UploadStream(fileName string, reader io.Reader, size int64, options *UploadOptions) error {
+	input := &obs.PutObjectInput{}
+	input.Bucket = obsClient.BucketName
+	input.Key = fileName
+	input.Body = reader
+	if size >= 0 {
+		input.ContentLength = size
+	}
+	if options != nil {
+		input.ContentType = options.ContentType
+		input.Metadata = options.Metadata
+	}
+	_, err := obsClient.ObsClient.PutObject(input)
+	if err != nil {
+		if obsError, ok := err.(obs.ObsError); ok {
+			klog.Error(obsError.Code)
+			klog.Error(obsError.Message)
+		}
+		return err
+	}
+	return obsClient.setHttpHeaders(obsClient.BucketName, fileName, options)
+}
+
+// setHttpHeaders 通过设置对象元数据写入上传接口不支持的 Content-Disposition、Cache-Control 与 Expires
+func (obsClient *obsClientImpl) setHttpHeaders(bucketName, fileName string, options *UploadOptions) error {
+	if options == nil || (options.ContentDisposition == "" && options.CacheControl == "" && options.Expires.IsZero()) {
+		return nil
+	}
+	metadataInput := &obs.SetObjectMetadataInput{Bucket: bucketName, Key: fileName,
+		MetadataDirective: obs.ReplaceNew, ContentDisposition: options.ContentDisposition,
+		CacheControl: options.CacheControl}
+	if !options.Expires.IsZero() {
+		metadataInput.Expires = options.Expires.UTC().Format(http.TimeFormat)
+	}
+	_, err := obsClient.ObsClient.SetObjectMetadata(metadataInput)
+	if err != nil {
+		if obsError, ok := err.(obs.ObsError); ok {
+			klog.Error(obsError.Code)
+			klog.Error(obsError.Message)
+		}
+	}
+	return err
+}
+
+// OpenObject https://support.huaweicloud.com/sdk-go-devg-obs/obs_23_0502.html
+/**
+ * 功能描述：流式读取obs中的文件，调用方读取完成后需要关闭
+ * @param fileName 文件名称
+ * @return io.ReadCloser, error
+ */
+func (obsClient *obsClientImpl) OpenObject(fileName string) (io.ReadCloser, error) {
+	input := &obs.GetObjectInput{}
+	input.Bucket = obsClient.BucketName
+	input.Key = fileName
+	return obsClient.getObject(input)
+}
+
+// OpenObjectRange https://support.huaweicloud.com/sdk-go-devg-obs/obs_23_0504.html
+/**
+ * 功能描述：流式读取obs中文件的指定范围，调用方读取完成后需要关闭
+ * @param fileName 文件名称
+ * @param offset 起始位置，从0开始
+ * @param length 读取长度，小于0表示读取到文件末尾
+ * @return io.ReadCloser, error
+ */
+func (obsClient *obsClientImpl) OpenObjectRange(fileName string, offset, length int64) (io.ReadCloser, error) {
+	if err := checkRange(offset, length); err != nil {
+		return nil, err
+	}
+	input := &obs.GetObjectInput{}
+	input.Bucket = obsClient.BucketName
+	input.Key = fileName
+	input.RangeStart = offset
+	// sdk只在 RangeEnd > RangeStart 时设置Range，读取到末尾时使用最大值，只读1个字节时多读1个字节再截断
+	input.RangeEnd = math.MaxInt64
+	if length > 0 {
+		input.RangeEnd = offset + length - 1
+		if length == 1 {
+			input.RangeEnd++
+		}
+	}
+	body, err := obsClient.getObject(input)
+	if err != nil || length < 0 {
+		return body, err
+	}
+	return newLimitReadCloser(body, length), nil
+}
+
+func (obsClient *obsClientImpl) getObject(input *obs.GetObjectInput) (io.ReadCloser, error) {
+	output, err := obsClient.ObsClient.GetObject(input)
+	if err != nil {
+		if obsError, ok := err.(obs.ObsError); ok {
+			klog.Error(obsError.Code)
+			klog.Error(obsError.Message)
+			return nil, obsNotFound(obsError, input.Key)
+		}
+		return nil, err
+	}
+	return output.Body, nil
+}
+
+// UploadFileMultipart https://support.huaweicloud.com/sdk-go-devg-obs/obs_23_0409.html
+/**
+ * 功能描述：将本地文件分片并发上传至obs指定桶中，支持断点续传
+ * @param fileName 对应obs的文件名
+ * @param localFile 本地文件路径
+ * @param options 分片上传选项，可以为nil
+ * @return error
+ */
+func (obsClient *obsClientImpl) UploadFileMultipart(fileName, localFile string, options *MultipartOptions) error {
+	multipart := options.withDefaults(localFile)
+	input := &obs.UploadFileInput{}
+	input.Bucket = obsClient.BucketName
+	input.Key = fileName
+	input.UploadFile = localFile
+	// 开启断点续传模式
+	input.EnableCheckpoint = true
+	input.CheckpointFile = multipart.CheckpointFile
+	input.PartSize = multipart.PartSize
+	input.TaskNum = multipart.Concurrency
+	input.ContentType = multipart.ContentType
+	input.Metadata = multipart.Metadata
+	_, err := obsClient.ObsClient.UploadFile(input)
+	if err != nil {
+		if obsError, ok := err.(obs.ObsError); ok {
+			klog.Error(obsError.Code)
+			klog.Error(obsError.Message)
+		}
+		return err
+	}
+	return obsClient.setHttpHeaders(obsClient.BucketName, fileName, &multipart.UploadOptions)
+}
+
+// List https://support.huaweicloud.com/sdk-go-devg-obs/obs_23_0602.html
+/**
+ * 功能描述：列举obs指定桶中的文件，迭代时自动翻页
+ * @param prefix 文件名前缀，为空时列举所有文件
+ * @param delimiter 分隔符，例如 /，不为空时按分隔符折叠为公共前缀
+ * @return ObjectIterator
+ */
+func (obsClient *obsClientImpl) List(prefix, delimiter string) ObjectIterator {
+	return newObjectIterator(func(marker string) ([]ObjectInfo, string, bool, error) {
+		input := &obs.ListObjectsInput{}
+		input.Bucket = obsClient.BucketName
+		input.Prefix = prefix
+		input.Delimiter = delimiter
+		input.Marker = marker
+		input.MaxKeys = listPageSize
+		output, err := obsClient.ObsClient.ListObjects(input)
+		if err != nil {
+			if obsError, ok := err.(obs.ObsError); ok {
+				klog.Error(obsError.Code)
+				klog.Error(obsError.Message)
+			}
+			return nil, "", false, err
+		}
+		objects := make([]ObjectInfo, 0, len(output.Contents)+len(output.CommonPrefixes))
+		for _, content := range output.Contents {
+			objects = append(objects, ObjectInfo{Key: content.Key, Size: content.Size, ETag: trimETag(content.ETag),
+				LastModified: content.LastModified})
+		}
+		return listResult(objects, output.CommonPrefixes), output.NextMarker, output.IsTruncated, nil
+	})
+}
+
+// Stat https://support.huaweicloud.com/sdk-go-devg-obs/obs_23_0802.html
+/**
+ * 功能描述：获取obs中文件的元数据，文件不存在时返回的错误可以使用 IsNotFound 判断
+ * @param fileName 文件名称
+ * @return *ObjectStat, error
+ */
+func (obsClient *obsClientImpl) Stat(fileName string) (*ObjectStat, error) {
+	input := &obs.GetObjectMetadataInput{Bucket: obsClient.BucketName, Key: fileName}
+	output, err := obsClient.ObsClient.GetObjectMetadata(input)
+	if err != nil {
+		if obsError, ok := err.(obs.ObsError); ok {
+			if err = obsNotFound(obsError, fileName); !IsNotFound(err) {
+				klog.Error(obsError.Code)
+				klog.Error(obsError.Message)
+			}
+		}
+		return nil, err
+	}
+	stat := &ObjectStat{Key: fileName, Size: output.ContentLength, ETag: trimETag(output.ETag),
+		ContentType: output.ContentType, LastModified: output.LastModified, Metadata: map[string]string{}}
+	for key, value := range output.Metadata {
+		stat.Metadata[strings.ToLower(key)] = value
+	}
+	return stat, nil
+}
+
+// Exists
+/**
+ * 功能描述：判断obs中文件是否存在
+ * @param fileName 文件名称
+ * @return bool, error
+ */
+func (obsClient *obsClientImpl) Exists(fileName string) (bool, error) {
+	_, err := obsClient.Stat(fileName)
+	if IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// obsNotFound 将obs返回的404转换为 IsNotFound 可以判断的错误
+func obsNotFound(obsError obs.ObsError, fileName string) error {
+	if obsError.StatusCode == http.StatusNotFound || obsError.Code == "404" {
+		return newNotFound(fileName)
+	}
+	return obsError
+}
+
+// Copy https://support.huaweicloud.com/sdk-go-devg-obs/obs_23_0806.html
+/**
+ * 功能描述：服务端复制obs中的文件，超过 CopyPartThreshold 的文件使用分片复制
+ * @param srcName 源文件名称
+ * @param destName 目标文件名称
+ * @param options 复制选项，可以为nil
+ * @return error
+ */
+func (obsClient *obsClientImpl) Copy(srcName, destName string, options *CopyOptions) error {
+	meta, err := obsClient.ObsClient.GetObjectMetadata(&obs.GetObjectMetadataInput{Bucket: obsClient.BucketName, Key: srcName})
+	if err != nil {
+		if obsError, ok := err.(obs.ObsError); ok {
+			klog.Error(obsError.Code)
+			klog.Error(obsError.Message)
+			return obsNotFound(obsError, srcName)
+		}
+		return err
+	}
+	destBucketName := options.destBucket(obsClient.BucketName)
+	metadata := options.replaceMetadata()
+
+	if meta.ContentLength <= CopyPartThreshold {
+		input := &obs.CopyObjectInput{}
+		input.Bucket = destBucketName
+		input.Key = destName
+		input.CopySourceBucket = obsClient.BucketName
+		input.CopySourceKey = srcName
+		input.MetadataDirective = obs.CopyMetadata
+		if metadata != nil {
+			input.MetadataDirective = obs.ReplaceMetadata
+			input.ContentType = metadata.ContentType
+			input.ContentDisposition = metadata.ContentDisposition
+			input.CacheControl = metadata.CacheControl
+			input.Metadata = metadata.Metadata
+			if !metadata.Expires.IsZero() {
+				input.Expires = metadata.Expires.UTC().Format(http.TimeFormat)
+			}
+		}
+		_, err = obsClient.ObsClient.CopyObject(input)
+		if err != nil {
+			if obsError, ok := err.(obs.ObsError); ok {
+				klog.Error(obsError.Code)
+				klog.Error(obsError.Message)
+			}
+		}
+		return err
+	}
+
+	// 分片复制不会复制源文件的元数据，需要在初始化分片时指定
+	if metadata == nil {
+		metadata = &UploadOptions{ContentType: meta.ContentType, Metadata: meta.Metadata,
+			ContentDisposition: obsResponseHeader(meta.BaseModel, "content-disposition"),
+			CacheControl:       obsResponseHeader(meta.BaseModel, "cache-control")}
+		metadata.Expires, _ = http.ParseTime(obsResponseHeader(meta.BaseModel, "expires"))
+	}
+	if err = obsClient.copyMultipart(srcName, destBucketName, destName, meta.ContentLength, metadata); err != nil {
+		if obsError, ok := err.(obs.ObsError); ok {
+			klog.Error(obsError.Code)
+			klog.Error(obsError.Message)
+		}
+		return err
+	}
+	return obsClient.setHttpHeaders(destBucketName, destName, metadata)
+}
+
+// copyMultipart 并发分片复制，失败时取消分片任务
+func (obsClient *obsClientImpl) copyMultipart(srcName, destBucketName, destName string, size int64, metadata *UploadOptions) error {
+	initInput := &obs.InitiateMultipartUploadInput{}
+	initInput.Bucket = destBucketName
+	initInput.Key = destName
+	initInput.ContentType = metadata.ContentType
+	initInput.Metadata = metadata.Metadata
+	initOutput, err := obsClient.ObsClient.InitiateMultipartUpload(initInput)
+	if err != nil {
+		return err
+	}
+
+	ranges := copyPartRanges(size, CopyPartSize)
+	parts := make([]obs.Part, len(ranges))
+	errs := make([]error, len(ranges))
+	tasks := make(chan int)
+	wg := sync.WaitGroup{}
+	for i := 0; i < DefaultConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range tasks {
+				output, err := obsClient.ObsClient.CopyPart(&obs.CopyPartInput{Bucket: destBucketName, Key: destName,
+					UploadId: initOutput.UploadId, PartNumber: index + 1, CopySourceBucket: obsClient.BucketName,
+					CopySourceKey: srcName, CopySourceRangeStart: ranges[index][0], CopySourceRangeEnd: ranges[index][1]})
+				if err != nil {
+					errs[index] = err
+					continue
+				}
+				parts[index] = obs.Part{PartNumber: index + 1, ETag: output.ETag}
+			}
+		}()
+	}
+	for index := range ranges {
+		tasks <- index
+	}
+	close(tasks)
+	wg.Wait()
+
+	for _, err = range errs {
+		if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		sort.Slice(parts, func(i, j int) bool {
+			return parts[i].PartNumber < parts[j].PartNumber
+		})
+		_, err = obsClient.ObsClient.CompleteMultipartUpload(&obs.CompleteMultipartUploadInput{Bucket: destBucketName,
+			Key: destName, UploadId: initOutput.UploadId, Parts: parts})
+	}
+	if err != nil {
+		_, abortErr := obsClient.ObsClient.AbortMultipartUpload(&obs.AbortMultipartUploadInput{Bucket: destBucketName,
+			Key: destName, UploadId: initOutput.UploadId})
+		if abortErr != nil {
+			klog.Error(abortErr)
+		}
+	}
+	return err
+}
+
+// copyPartRanges 计算分片复制的范围 [start, end]
+// sdk只在 RangeEnd > RangeStart 时设置范围，最后一个分片只剩1个字节时合并到前一个分片
+func copyPartRanges(size, partSize int64) [][2]int64 {
+	var ranges [][2]int64
+	for start := int64(0); start < size; {
+		end := start + partSize - 1
+		if end >= size-2 {
+			end = size - 1
+		}
+		ranges = append(ranges, [2]int64{start, end})
+		start = end + 1
+	}
+	return ranges
+}
+
+// Move
+/**
+ * 功能描述：服务端复制obs中的文件后删除源文件
+ * @param srcName 源文件名称
+ * @param destName 目标文件名称
+ * @param options 复制选项，可以为nil
+ * @return error
+ */
+func (obsClient *obsClientImpl) Move(srcName, destName string, options *CopyOptions) error {
+	if err := obsClient.Copy(srcName, destName, options); err != nil {
+		return err
+	}
+	if srcName == destName && options.destBucket(obsClient.BucketName) == obsClient.BucketName {
+		return nil
+	}
+	return obsClient.DeleteFiles([]string{srcName})
+}
+
+// obsResponseHeader 获取响应头，sdk中响应头的名称均为小写
+func obsResponseHeader(model obs.BaseModel, key string) string {
+	if values := model.ResponseHeaders[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
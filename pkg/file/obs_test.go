@@ -2,7 +2,12 @@ package file
 
 import (
 	"fmt"
+	"io/ioutil"
 	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -52,6 +57,30 @@ func TestObsDownloadFile(t *testing.T) {
 	log.Println("已成功下载")
 }
 
+// TestObsDownloadFileLocalError 本地文件无法创建时返回错误
+func TestObsDownloadFileLocalError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"5d41402abc4b2a76b9719d911017c592"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		w.Header().Set("Content-Length", "5")
+	}))
+	defer server.Close()
+	client, err := newObsClient(&CloudVendors{ServerType: obsType, BucketName: "bucket", Endpoint: server.URL,
+		Ak: "ak", Sk: "sk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	//父目录是一个文件，SDK无法创建本地文件
+	parent := filepath.Join(t.TempDir(), "file")
+	if err = ioutil.WriteFile(parent, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if localFile, err := client.DownloadFile("a.txt", filepath.Join(parent, "a.txt")); err == nil || localFile != "" {
+		t.Fatalf("expect error, but got %q, %v", localFile, err)
+	}
+}
+
 func TestObsUploadFile(t *testing.T) {
 	client := CreateObsClient()
 	defer client.Close()
@@ -62,3 +91,93 @@ func TestObsUploadFile(t *testing.T) {
 	}
 	log.Println("已成功上传")
 }
+
+func TestObsUploadStream(t *testing.T) {
+	client := CreateObsClient()
+	defer client.Close()
+	content := "{\"name\":\"test\"}"
+	err := client.UploadStream("gitlog/stream.json", strings.NewReader(content), int64(len(content)), &UploadOptions{
+		ContentType:        "application/json",
+		ContentDisposition: "attachment; filename=\"stream.json\"",
+		CacheControl:       "no-cache",
+		Metadata:           map[string]string{"source": "gitlog"},
+	})
+	if nil != err {
+		panic(err)
+	}
+	log.Println("已成功上传")
+}
+
+func TestObsOpenObject(t *testing.T) {
+	client := CreateObsClient()
+	defer client.Close()
+	body, err := client.OpenObjectRange("gitlog/stream.json", 2, 4)
+	if nil != err {
+		panic(err)
+	}
+	defer body.Close()
+	data, err := ioutil.ReadAll(body)
+	if nil != err {
+		panic(err)
+	}
+	log.Println("已读取：", string(data))
+}
+
+func TestObsUploadFileMultipart(t *testing.T) {
+	client := CreateObsClient()
+	defer client.Close()
+	err := client.UploadFileMultipart("artifacts/app.tar.gz", "/app.tar.gz", &MultipartOptions{
+		PartSize:    5 * 1024 * 1024,
+		Concurrency: 3,
+	})
+	if nil != err {
+		panic(err)
+	}
+	log.Println("已成功上传")
+}
+
+func TestObsList(t *testing.T) {
+	client := CreateObsClient()
+	defer client.Close()
+	iterator := client.List("gitlog/", "/")
+	for iterator.Next() {
+		object := iterator.Object()
+		log.Println(object.Key, object.Size, object.ETag, object.LastModified, object.IsPrefix)
+	}
+	if err := iterator.Err(); nil != err {
+		panic(err)
+	}
+}
+
+func TestObsStat(t *testing.T) {
+	client := CreateObsClient()
+	defer client.Close()
+	stat, err := client.Stat("gitlog/stream.json")
+	if nil != err {
+		panic(err)
+	}
+	log.Println(stat.Size, stat.ETag, stat.ContentType, stat.LastModified, stat.Metadata)
+	exists, err := client.Exists("gitlog/not-exists.json")
+	if nil != err {
+		panic(err)
+	}
+	if exists {
+		t.Fatal("expect not exists")
+	}
+}
+
+func TestObsCopy(t *testing.T) {
+	client := CreateObsClient()
+	defer client.Close()
+	err := client.Copy("staging/app.tar.gz", "release/app.tar.gz", nil)
+	if nil != err {
+		panic(err)
+	}
+	err = client.Move("staging/app.json", "release/app.json", &CopyOptions{
+		Metadata: &UploadOptions{ContentType: "application/json"},
+	})
+	if nil != err {
+		panic(err)
+	}
+	log.Println("已成功复制")
+}
@@ -2,6 +2,12 @@ package file
 
 import (
 	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
 	"k8s.io/klog/v2"
 )
@@ -60,7 +66,7 @@ func (ossClient *ossClientImpl) DownloadFile(fileName, localFile string) (string
 	err = bucket.GetObjectToFile(fileName, localFile)
 	if err != nil {
 		klog.Error(err)
-		return "", err
+		return "", ossError(err, fileName)
 	}
 	return localFile, nil
 }
@@ -125,3 +131,289 @@ func (ossClient *ossClientImpl) UploadFile(fileName string, content []byte) erro
 	}
 	return err
 }
+
+// UploadStream https://help.aliyun.com/document_detail/88601.html#section-dv9-wut-ect
+/**
+ * 功能描述：将reader中的内容流式上传至oss指定桶中，不会将内容全部读入内存
+ * @param fileName 对应oss的文件名
+ * @param reader 文件内容
+ * @param size 文件大小，小于0表示未知
+ * @param options 上传选项，可以为nil
+ * @return error
+ */
+func (ossClient *ossClientImpl) UploadStream(fileName string, reader io.Reader, size int64, options *UploadOptions) error {
+	bucket, err := ossClient.OssClient.Bucket(ossClient.BucketName)
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	ossOptions := ossUploadOptions(options)
+	if size >= 0 {
+		ossOptions = append(ossOptions, oss.ContentLength(size))
+	}
+	err = bucket.PutObject(fileName, reader, ossOptions...)
+	if err != nil {
+		klog.Error(err)
+	}
+	return err
+}
+
+// ossUploadOptions 将上传选项转换为oss的请求头
+func ossUploadOptions(options *UploadOptions) []oss.Option {
+	var ossOptions []oss.Option
+	if options == nil {
+		return ossOptions
+	}
+	if options.ContentType != "" {
+		ossOptions = append(ossOptions, oss.ContentType(options.ContentType))
+	}
+	if options.ContentDisposition != "" {
+		ossOptions = append(ossOptions, oss.ContentDisposition(options.ContentDisposition))
+	}
+	if options.CacheControl != "" {
+		ossOptions = append(ossOptions, oss.CacheControl(options.CacheControl))
+	}
+	if !options.Expires.IsZero() {
+		ossOptions = append(ossOptions, oss.Expires(options.Expires))
+	}
+	for key, value := range options.Metadata {
+		ossOptions = append(ossOptions, oss.Meta(key, value))
+	}
+	return ossOptions
+}
+
+// OpenObject https://help.aliyun.com/document_detail/88614.html
+/**
+ * 功能描述：流式读取oss中的文件，调用方读取完成后需要关闭
+ * @param fileName 文件名称
+ * @return io.ReadCloser, error
+ */
+func (ossClient *ossClientImpl) OpenObject(fileName string) (io.ReadCloser, error) {
+	bucket, err := ossClient.OssClient.Bucket(ossClient.BucketName)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	body, err := bucket.GetObject(fileName)
+	if err != nil {
+		klog.Error(err)
+		return nil, ossError(err, fileName)
+	}
+	return body, nil
+}
+
+// OpenObjectRange https://help.aliyun.com/document_detail/88617.html
+/**
+ * 功能描述：流式读取oss中文件的指定范围，调用方读取完成后需要关闭
+ * @param fileName 文件名称
+ * @param offset 起始位置，从0开始
+ * @param length 读取长度，小于0表示读取到文件末尾
+ * @return io.ReadCloser, error
+ */
+func (ossClient *ossClientImpl) OpenObjectRange(fileName string, offset, length int64) (io.ReadCloser, error) {
+	if err := checkRange(offset, length); err != nil {
+		return nil, err
+	}
+	bucket, err := ossClient.OssClient.Bucket(ossClient.BucketName)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	rangeOption := oss.NormalizedRange(fmt.Sprintf("%d-", offset))
+	if length > 0 {
+		rangeOption = oss.Range(offset, offset+length-1)
+	}
+	// 指定 x-oss-range-behavior:standard，范围超出文件大小时返回错误，而不是返回整个文件
+	body, err := bucket.GetObject(fileName, rangeOption, oss.RangeBehavior("standard"))
+	if err != nil {
+		klog.Error(err)
+		return nil, ossError(err, fileName)
+	}
+	return body, nil
+}
+
+// UploadFileMultipart https://help.aliyun.com/document_detail/88603.html
+/**
+ * 功能描述：将本地文件分片并发上传至oss指定桶中，支持断点续传
+ * @param fileName 对应oss的文件名
+ * @param localFile 本地文件路径
+ * @param options 分片上传选项，可以为nil
+ * @return error
+ */
+func (ossClient *ossClientImpl) UploadFileMultipart(fileName, localFile string, options *MultipartOptions) error {
+	bucket, err := ossClient.OssClient.Bucket(ossClient.BucketName)
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	multipart := options.withDefaults(localFile)
+	ossOptions := append(ossUploadOptions(&multipart.UploadOptions),
+		oss.Routines(multipart.Concurrency), oss.Checkpoint(true, multipart.CheckpointFile))
+	err = bucket.UploadFile(fileName, localFile, multipart.PartSize, ossOptions...)
+	if err != nil {
+		klog.Error(err)
+	}
+	return err
+}
+
+// List https://help.aliyun.com/document_detail/88643.html
+/**
+ * 功能描述：列举oss指定桶中的文件，迭代时自动翻页
+ * @param prefix 文件名前缀，为空时列举所有文件
+ * @param delimiter 分隔符，例如 /，不为空时按分隔符折叠为公共前缀
+ * @return ObjectIterator
+ */
+func (ossClient *ossClientImpl) List(prefix, delimiter string) ObjectIterator {
+	bucket, err := ossClient.OssClient.Bucket(ossClient.BucketName)
+	if err != nil {
+		klog.Error(err)
+		return newErrorIterator(err)
+	}
+	return newObjectIterator(func(marker string) ([]ObjectInfo, string, bool, error) {
+		result, err := bucket.ListObjects(oss.Prefix(prefix), oss.Delimiter(delimiter), oss.Marker(marker),
+			oss.MaxKeys(listPageSize))
+		if err != nil {
+			klog.Error(err)
+			return nil, "", false, err
+		}
+		objects := make([]ObjectInfo, 0, len(result.Objects)+len(result.CommonPrefixes))
+		for _, object := range result.Objects {
+			objects = append(objects, ObjectInfo{Key: object.Key, Size: object.Size, ETag: trimETag(object.ETag),
+				LastModified: object.LastModified})
+		}
+		return listResult(objects, result.CommonPrefixes), result.NextMarker, result.IsTruncated, nil
+	})
+}
+
+// Stat https://help.aliyun.com/document_detail/88637.html
+/**
+ * 功能描述：获取oss中文件的元数据，文件不存在时返回的错误可以使用 IsNotFound 判断
+ * @param fileName 文件名称
+ * @return *ObjectStat, error
+ */
+func (ossClient *ossClientImpl) Stat(fileName string) (*ObjectStat, error) {
+	bucket, err := ossClient.OssClient.Bucket(ossClient.BucketName)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	header, err := bucket.GetObjectDetailedMeta(fileName)
+	if err != nil {
+		err = ossError(err, fileName)
+		if !IsNotFound(err) {
+			klog.Error(err)
+		}
+		return nil, err
+	}
+	stat := &ObjectStat{Key: fileName, ETag: trimETag(header.Get(oss.HTTPHeaderEtag)),
+		ContentType: header.Get(oss.HTTPHeaderContentType), Metadata: ossMetadata(header)}
+	stat.Size, _ = strconv.ParseInt(header.Get(oss.HTTPHeaderContentLength), 10, 64)
+	stat.LastModified, _ = http.ParseTime(header.Get(oss.HTTPHeaderLastModified))
+	return stat, nil
+}
+
+// ossMetadata 从响应头中获取用户自定义元数据
+func ossMetadata(header http.Header) map[string]string {
+	metadata := map[string]string{}
+	for key, values := range header {
+		if strings.HasPrefix(key, oss.HTTPHeaderOssMetaPrefix) && len(values) > 0 {
+			metadata[strings.ToLower(strings.TrimPrefix(key, oss.HTTPHeaderOssMetaPrefix))] = values[0]
+		}
+	}
+	return metadata
+}
+
+// Exists
+/**
+ * 功能描述：判断oss中文件是否存在
+ * @param fileName 文件名称
+ * @return bool, error
+ */
+func (ossClient *ossClientImpl) Exists(fileName string) (bool, error) {
+	_, err := ossClient.Stat(fileName)
+	if IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ossError 将oss返回的404转换为 IsNotFound 可以判断的错误
+func ossError(err error, fileName string) error {
+	if serviceError, ok := err.(oss.ServiceError); ok && serviceError.StatusCode == http.StatusNotFound {
+		return newNotFound(fileName)
+	}
+	return err
+}
+
+// Copy https://help.aliyun.com/document_detail/88634.html
+/**
+ * 功能描述：服务端复制oss中的文件，超过 CopyPartThreshold 的文件使用分片复制
+ * @param srcName 源文件名称
+ * @param destName 目标文件名称
+ * @param options 复制选项，可以为nil
+ * @return error
+ */
+func (ossClient *ossClientImpl) Copy(srcName, destName string, options *CopyOptions) error {
+	srcBucket, err := ossClient.OssClient.Bucket(ossClient.BucketName)
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	header, err := srcBucket.GetObjectDetailedMeta(srcName)
+	if err != nil {
+		klog.Error(err)
+		return ossError(err, srcName)
+	}
+	size, _ := strconv.ParseInt(header.Get(oss.HTTPHeaderContentLength), 10, 64)
+	destBucketName := options.destBucket(ossClient.BucketName)
+	metadata := options.replaceMetadata()
+
+	if size <= CopyPartThreshold {
+		var ossOptions []oss.Option
+		if metadata != nil {
+			ossOptions = append(ossUploadOptions(metadata), oss.MetadataDirective(oss.MetaReplace))
+		}
+		_, err = srcBucket.CopyObjectTo(destBucketName, destName, srcName, ossOptions...)
+		if err != nil {
+			klog.Error(err)
+		}
+		return err
+	}
+
+	// 分片复制不会复制源文件的元数据，需要在初始化分片时指定
+	if metadata == nil {
+		metadata = &UploadOptions{ContentType: header.Get(oss.HTTPHeaderContentType),
+			ContentDisposition: header.Get(oss.HTTPHeaderContentDisposition),
+			CacheControl:       header.Get(oss.HTTPHeaderCacheControl), Metadata: ossMetadata(header)}
+		metadata.Expires, _ = http.ParseTime(header.Get(oss.HTTPHeaderExpires))
+	}
+	destBucket, err := ossClient.OssClient.Bucket(destBucketName)
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	ossOptions := append(ossUploadOptions(metadata), oss.Routines(DefaultConcurrency))
+	err = destBucket.CopyFile(ossClient.BucketName, srcName, destName, CopyPartSize, ossOptions...)
+	if err != nil {
+		klog.Error(err)
+	}
+	return err
+}
+
+// Move
+/**
+ * 功能描述：服务端复制oss中的文件后删除源文件
+ * @param srcName 源文件名称
+ * @param destName 目标文件名称
+ * @param options 复制选项，可以为nil
+ * @return error
+ */
+func (ossClient *ossClientImpl) Move(srcName, destName string, options *CopyOptions) error {
+	if err := ossClient.Copy(srcName, destName, options); err != nil {
+		return err
+	}
+	if srcName == destName && options.destBucket(ossClient.BucketName) == ossClient.BucketName {
+		return nil
+	}
+	return ossClient.DeleteFiles([]string{srcName})
+}
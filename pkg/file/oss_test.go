@@ -2,7 +2,9 @@ package file
 
 import (
 	"fmt"
+	"io/ioutil"
 	"log"
+	"strings"
 	"testing"
 )
 
@@ -62,3 +64,93 @@ func TestOssUploadFile(t *testing.T) {
 	}
 	log.Println("已成功上传")
 }
+
+func TestOssUploadStream(t *testing.T) {
+	client := CreateOssClient()
+	defer client.Close()
+	content := "{\"name\":\"test\"}"
+	err := client.UploadStream("gitlog/stream.json", strings.NewReader(content), int64(len(content)), &UploadOptions{
+		ContentType:        "application/json",
+		ContentDisposition: "attachment; filename=\"stream.json\"",
+		CacheControl:       "no-cache",
+		Metadata:           map[string]string{"source": "gitlog"},
+	})
+	if nil != err {
+		panic(err)
+	}
+	log.Println("已成功上传")
+}
+
+func TestOssOpenObject(t *testing.T) {
+	client := CreateOssClient()
+	defer client.Close()
+	body, err := client.OpenObjectRange("gitlog/stream.json", 2, 4)
+	if nil != err {
+		panic(err)
+	}
+	defer body.Close()
+	data, err := ioutil.ReadAll(body)
+	if nil != err {
+		panic(err)
+	}
+	log.Println("已读取：", string(data))
+}
+
+func TestOssUploadFileMultipart(t *testing.T) {
+	client := CreateOssClient()
+	defer client.Close()
+	err := client.UploadFileMultipart("artifacts/app.tar.gz", "/app.tar.gz", &MultipartOptions{
+		PartSize:    5 * 1024 * 1024,
+		Concurrency: 3,
+	})
+	if nil != err {
+		panic(err)
+	}
+	log.Println("已成功上传")
+}
+
+func TestOssList(t *testing.T) {
+	client := CreateOssClient()
+	defer client.Close()
+	iterator := client.List("gitlog/", "/")
+	for iterator.Next() {
+		object := iterator.Object()
+		log.Println(object.Key, object.Size, object.ETag, object.LastModified, object.IsPrefix)
+	}
+	if err := iterator.Err(); nil != err {
+		panic(err)
+	}
+}
+
+func TestOssStat(t *testing.T) {
+	client := CreateOssClient()
+	defer client.Close()
+	stat, err := client.Stat("gitlog/stream.json")
+	if nil != err {
+		panic(err)
+	}
+	log.Println(stat.Size, stat.ETag, stat.ContentType, stat.LastModified, stat.Metadata)
+	exists, err := client.Exists("gitlog/not-exists.json")
+	if nil != err {
+		panic(err)
+	}
+	if exists {
+		t.Fatal("expect not exists")
+	}
+}
+
+func TestOssCopy(t *testing.T) {
+	client := CreateOssClient()
+	defer client.Close()
+	err := client.Copy("staging/app.tar.gz", "release/app.tar.gz", nil)
+	if nil != err {
+		panic(err)
+	}
+	err = client.Move("staging/app.json", "release/app.json", &CopyOptions{
+		Metadata: &UploadOptions{ContentType: "application/json"},
+	})
+	if nil != err {
+		panic(err)
+	}
+	log.Println("已成功复制")
+}
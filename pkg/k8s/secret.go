@@ -0,0 +1,220 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	"github.com/lstack-org/utils/pkg/encipherment"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	secretGroupVersionResource = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "secrets"}
+)
+
+// YamlsApplyEncrypted 解密manifest中的加密Secret后执行 YamlsApply，明文只保存在内存中
+// 加密Secret的 data/stringData 值为 ENC(<密文>)，见 EncryptSecretManifests、ExportEncryptedSecret
+func YamlsApplyEncrypted(ctx context.Context, client Interface, reader io.Reader, cipher encipherment.FieldCipher, dryrun ...string) error {
+	manifest, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	decrypted, err := DecryptSecretManifests(string(manifest), cipher)
+	if err != nil {
+		return err
+	}
+	return client.YamlsApply(ctx, bytes.NewReader(decrypted), dryrun...)
+}
+
+// DecryptSecretManifests 解密manifest中Secret的 ENC(...) 值，返回可直接apply的明文manifest
+// data 中的值解密后按k8s要求进行base64编码，stringData 中的值解密后保持原样，其他资源原样输出
+func DecryptSecretManifests(manifest string, cipher encipherment.FieldCipher) ([]byte, error) {
+	return transformSecrets(manifest, func(secret *unstructured.Unstructured) error {
+		return cryptSecret(secret, func(value string, base64Encoded bool) (string, error) {
+			ciphertext, ok := encipherment.ParseEncValue(value)
+			if !ok {
+				return value, nil
+			}
+			plaintext, err := cipher.Open(ciphertext, nil)
+			if err != nil {
+				return "", err
+			}
+			if base64Encoded {
+				return base64.StdEncoding.EncodeToString(plaintext), nil
+			}
+			return string(plaintext), nil
+		})
+	})
+}
+
+// EncryptSecretManifests 加密manifest中Secret的 data/stringData 值，返回可以提交到git的manifest
+// data 中的值先进行base64解码，加密的是Secret的原始内容，已经加密的值不会重复加密
+func EncryptSecretManifests(manifest string, cipher encipherment.FieldCipher) ([]byte, error) {
+	return transformSecrets(manifest, func(secret *unstructured.Unstructured) error {
+		return cryptSecret(secret, func(value string, base64Encoded bool) (string, error) {
+			if _, ok := encipherment.ParseEncValue(value); ok {
+				return value, nil
+			}
+			plaintext := []byte(value)
+			if base64Encoded {
+				var err error
+				plaintext, err = base64.StdEncoding.DecodeString(value)
+				if err != nil {
+					return "", err
+				}
+			}
+			ciphertext, err := cipher.Seal(plaintext, nil)
+			if err != nil {
+				return "", err
+			}
+			return encipherment.FormatEncValue(ciphertext), nil
+		})
+	})
+}
+
+// ExportEncryptedSecret 将集群中的Secret导出为加密manifest
+// 只保留name、namespace、labels、annotations与type，data 中的值全部加密
+func ExportEncryptedSecret(client Interface, namespace, name string, cipher encipherment.FieldCipher) ([]byte, error) {
+	secret := &core.Secret{}
+	err := client.Resource(secretGroupVersionResource).Namespace(namespace).Get(name, secret, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return encryptedSecretManifest(secret, cipher)
+}
+
+// encryptedSecretManifest 生成Secret的加密manifest
+func encryptedSecretManifest(secret *core.Secret, cipher encipherment.FieldCipher) ([]byte, error) {
+	annotations := make(map[string]interface{})
+	for k, v := range secret.Annotations {
+		if k != AnnotationLastAppliedConfig && k != core.LastAppliedConfigAnnotation {
+			annotations[k] = v
+		}
+	}
+	labels := make(map[string]interface{})
+	for k, v := range secret.Labels {
+		labels[k] = v
+	}
+	data := make(map[string]interface{})
+	for k, v := range secret.Data {
+		ciphertext, err := cipher.Seal(v, nil)
+		if err != nil {
+			return nil, err
+		}
+		data[k] = encipherment.FormatEncValue(ciphertext)
+	}
+
+	metadata := map[string]interface{}{
+		"name":      secret.Name,
+		"namespace": secret.Namespace,
+	}
+	if len(labels) > 0 {
+		metadata["labels"] = labels
+	}
+	if len(annotations) > 0 {
+		metadata["annotations"] = annotations
+	}
+	object := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   metadata,
+		"data":       data,
+	}
+	if secret.Type != "" {
+		object["type"] = string(secret.Type)
+	}
+	return yaml.Marshal(object)
+}
+
+// transformSecrets 对manifest中的每个Secret执行fn，重新输出为多文档yaml
+func transformSecrets(manifest string, fn func(secret *unstructured.Unstructured) error) ([]byte, error) {
+	resources, err := parseManifests(manifest)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	for index, resource := range resources {
+		resource := resource
+		if resource.GetAPIVersion() == "v1" && resource.GetKind() == "Secret" {
+			if err := fn(&resource); err != nil {
+				return nil, fmt.Errorf("secret %s/%s: %w", resource.GetNamespace(), resource.GetName(), err)
+			}
+		}
+		data, err := yaml.Marshal(resource.Object)
+		if err != nil {
+			return nil, err
+		}
+		if index > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+// parseManifests 按文档顺序解析manifest，与 ManifestToResouces 不同，文档解析失败时返回错误，不会丢弃资源
+func parseManifests(manifest string) ([]unstructured.Unstructured, error) {
+	manifests := releaseutil.SplitManifests(manifest)
+	keys := make([]string, 0, len(manifests))
+	for key := range manifests {
+		keys = append(keys, key)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+
+	var objs []unstructured.Unstructured
+	for index, key := range keys {
+		var u unstructured.Unstructured
+		if err := yaml.Unmarshal([]byte(manifests[key]), &u.Object); err != nil {
+			return nil, fmt.Errorf("parse manifest document %d: %w", index, err)
+		}
+		//只有注释的文档
+		if len(u.Object) == 0 {
+			continue
+		}
+		if u.IsList() {
+			l, err := u.ToList()
+			if err != nil {
+				return nil, fmt.Errorf("parse manifest document %d: %w", index, err)
+			}
+			objs = append(objs, l.Items...)
+			continue
+		}
+		if u.GetKind() == "" || u.GetAPIVersion() == "" {
+			return nil, fmt.Errorf("parse manifest document %d: apiVersion and kind are required", index)
+		}
+		objs = append(objs, u)
+	}
+	return objs, nil
+}
+
+// cryptSecret 对Secret data/stringData 中的每个值执行fn
+func cryptSecret(secret *unstructured.Unstructured, fn func(value string, base64Encoded bool) (string, error)) error {
+	for field, base64Encoded := range map[string]bool{"data": true, "stringData": false} {
+		values, found, err := unstructured.NestedStringMap(secret.Object, field)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+		for k, v := range values {
+			if values[k], err = fn(v, base64Encoded); err != nil {
+				return fmt.Errorf("%s.%s: %w", field, k, err)
+			}
+		}
+		if err = unstructured.SetNestedStringMap(secret.Object, values, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package k8s
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/lstack-org/utils/pkg/encipherment"
+	core "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const secretManifest = `
+apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+  namespace: default
+type: Opaque
+data:
+  password: MTIzNDU2
+stringData:
+  user: root
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: db
+  namespace: default
+data:
+  host: localhost
+`
+
+func TestSecretManifests(t *testing.T) {
+	cipher := encipherment.NewFieldCipher([]byte("1234567890123456"))
+
+	encrypted, err := EncryptSecretManifests(secretManifest, cipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(encrypted, []byte("MTIzNDU2")) || bytes.Contains(encrypted, []byte("user: root")) {
+		t.Fatalf("plaintext left in encrypted manifest:\n%s", encrypted)
+	}
+	if !bytes.Contains(encrypted, []byte("host: localhost")) {
+		t.Fatalf("configmap should not be changed:\n%s", encrypted)
+	}
+
+	//再次加密不会改变已加密的值
+	again, err := EncryptSecretManifests(string(encrypted), cipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, encrypted) {
+		t.Fatalf("encrypt twice changed manifest:\n%s\n%s", encrypted, again)
+	}
+
+	decrypted, err := DecryptSecretManifests(string(encrypted), cipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resources := ManifestToResouces(string(decrypted))
+	if len(resources) != 2 {
+		t.Fatalf("expect 2 resources, but got %d", len(resources))
+	}
+	var secret map[string]interface{}
+	for _, resource := range resources {
+		if resource.GetKind() == "Secret" {
+			secret = resource.Object
+		}
+	}
+	if secret["data"].(map[string]interface{})["password"] != "MTIzNDU2" {
+		t.Fatalf("unexpected data: %v", secret["data"])
+	}
+	if secret["stringData"].(map[string]interface{})["user"] != "root" {
+		t.Fatalf("unexpected stringData: %v", secret["stringData"])
+	}
+
+	_, err = DecryptSecretManifests(string(encrypted), encipherment.NewFieldCipher([]byte("6543210987654321")))
+	if err == nil {
+		t.Fatal("expect error with wrong key")
+	}
+
+	//无法解析的文档不能被丢弃
+	for _, broken := range []string{secretManifest + "\n---\nkind: [\n", secretManifest + "\n---\nmetadata:\n  name: x\n"} {
+		if _, err = EncryptSecretManifests(broken, cipher); err == nil {
+			t.Fatal("expect error with broken document")
+		}
+		if _, err = DecryptSecretManifests(broken, cipher); err == nil {
+			t.Fatal("expect error with broken document")
+		}
+	}
+}
+
+func TestEncryptedSecretManifest(t *testing.T) {
+	secret := &core.Secret{}
+	secretStr := `{"kind":"Secret","apiVersion":"v1","metadata":{"name":"db","namespace":"default","labels":{"app":"db"},"annotations":{"kubectl.kubernetes.io/last-applied-configuration":"{}"}},"type":"Opaque","data":{"password":"MTIzNDU2"}}`
+	if err := yaml.Unmarshal([]byte(secretStr), secret); err != nil {
+		t.Fatal(err)
+	}
+
+	cipher := encipherment.NewFieldCipher([]byte("1234567890123456"))
+	exported, err := encryptedSecretManifest(secret, cipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(exported, []byte("MTIzNDU2")) || strings.Contains(string(exported), "last-applied-configuration") {
+		t.Fatalf("unexpected exported manifest:\n%s", exported)
+	}
+
+	decrypted, err := DecryptSecretManifests(string(exported), cipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret = &core.Secret{}
+	if err = yaml.Unmarshal(decrypted, secret); err != nil {
+		t.Fatal(err)
+	}
+	if string(secret.Data["password"]) != "123456" || secret.Labels["app"] != "db" || secret.Type != core.SecretTypeOpaque {
+		t.Fatalf("unexpected secret: %+v", secret)
+	}
+}